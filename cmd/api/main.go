@@ -7,6 +7,7 @@ import (
 	"bookcabin/internal/provider"
 	"bookcabin/internal/service"
 	"net/http"
+	"time"
 
 	_ "bookcabin/docs"
 
@@ -24,21 +25,60 @@ func main() {
 	mock.MockBatikServer()
 	mock.MockLionServer()
 	mock.MockGarudaServer()
+	mock.MockFXServer()
 	cache := infra.NewCache()
+	// drop entries past their stale window, even for keys never read again
+	stopSweeper := cache.StartSweeper(time.Minute)
+	defer stopSweeper()
+
+	// rates come from the local stand-in; infra.FileRateSource reads the
+	// same snapshot format from disk
+	fx := &infra.FXRates{
+		Source: infra.HTTPRateSource{URL: "http://127.0.0.1:8085/fx/rates", Client: provider.NewHTTPClient()},
+		TTL:    time.Hour,
+	}
 
 	uc := &service.SearchFlightsUseCase{
 		Providers: []service.FlightProvider{
-			&provider.AirAsiaProvider{BaseURL: "http://127.0.0.1:8081", Client: provider.NewHTTPClient()},
-			&provider.BatikProvider{BaseURL: "http://127.0.0.1:8082", Client: provider.NewHTTPClient()},
-			&provider.GarudaProvider{BaseURL: "http://127.0.0.1:8083", Client: provider.NewHTTPClient()},
-			&provider.LionAirProvider{BaseURL: "http://127.0.0.1:8084", Client: provider.NewHTTPClient()},
+			&provider.AirAsiaProvider{BaseURL: "http://127.0.0.1:8081", Client: provider.NewHTTPClient(), Retry: provider.DefaultRetryPolicy(), FX: fx},
+			&provider.BatikProvider{BaseURL: "http://127.0.0.1:8082", Client: provider.NewHTTPClient(), Retry: provider.DefaultRetryPolicy(), FX: fx},
+			&provider.GarudaProvider{BaseURL: "http://127.0.0.1:8083", Client: provider.NewHTTPClient(), Retry: provider.DefaultRetryPolicy(), FX: fx},
+			&provider.LionAirProvider{BaseURL: "http://127.0.0.1:8084", Client: provider.NewHTTPClient(), Retry: provider.DefaultRetryPolicy(), FX: fx},
+		},
+		Cache:            cache,
+		FX:               fx,
+		CacheTTL:         3 * time.Minute,
+		StaleTTL:         15 * time.Minute,
+		NegativeCacheTTL: 15 * time.Second,
+		MultiCityMinGap:  2 * time.Hour,
+		DayConcurrency:   4,
+		SnapshotTTL:      10 * time.Minute,
+		SelfTransfer: service.SelfTransferConfig{
+			Hubs:          []string{"CGK", "DPS", "SUB", "UPG"},
+			MinConnection: 90 * time.Minute,
+			MaxLayover:    8 * time.Hour,
+		},
+		CircuitBreaker: service.CircuitBreakerConfig{
+			FailureThreshold: 3,
+			CoolDown:         30 * time.Second,
+		},
+		Hedging: service.HedgeConfig{
+			// opt-in per provider; keep providers that bill per call off
+			Providers: map[string]bool{
+				"AirAsia":   true,
+				"Batik Air": true,
+				"Lion Air":  true,
+			},
+			MinDelay: 50 * time.Millisecond,
 		},
-		Cache: cache,
 	}
 
 	h := handler.NewFlightHandler(uc)
 
 	http.HandleFunc("/search", h.Search)
+	http.HandleFunc("/search/stream", h.SearchStream)
+	http.HandleFunc("/search/multi-city", h.SearchMultiCity)
+	http.HandleFunc("/calendar", h.Calendar)
 	http.Handle("/swagger/", httpSwagger.WrapHandler)
 	http.ListenAndServe(":8080", nil)
 }
@@ -15,6 +15,97 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/calendar": {
+            "get": {
+                "description": "Searches every day of the month on a route (bounded concurrency, cached days reused) and returns the lowest fare, its carrier and direct-flight availability per day. Days where a provider failed are marked incomplete.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Flights"
+                ],
+                "summary": "Lowest fare per day for a month",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Origin airport or city code (e.g. CGK, JKT)",
+                        "name": "origin",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Destination airport or city code (e.g. DPS)",
+                        "name": "destination",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Month (YYYY-MM)",
+                        "name": "month",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of passengers; flights with fewer seats left are dropped",
+                        "name": "passengers",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "economy",
+                            "premium_economy",
+                            "business",
+                            "first"
+                        ],
+                        "type": "string",
+                        "description": "Cabin class",
+                        "name": "cabin_class",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum stops",
+                        "name": "max_stops",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Airline codes (CSV or repeated), e.g. GA,ID",
+                        "name": "airlines",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.CalendarResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
         "/search": {
             "get": {
                 "description": "Search flights with filters and sorting",
@@ -31,14 +122,14 @@ const docTemplate = `{
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Origin airport code (e.g. CGK)",
+                        "description": "Origin airport or city code (e.g. CGK, JKT)",
                         "name": "origin",
                         "in": "query",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "Destination airport code (e.g. DPS)",
+                        "description": "Destination airport or city code (e.g. DPS)",
                         "name": "destination",
                         "in": "query",
                         "required": true
@@ -52,28 +143,68 @@ const docTemplate = `{
                     },
                     {
                         "type": "integer",
-                        "description": "Number of passengers",
+                        "description": "Number of passengers; flights with fewer seats left are dropped",
                         "name": "passengers",
                         "in": "query"
                     },
                     {
+                        "enum": [
+                            "economy",
+                            "premium_economy",
+                            "business",
+                            "first"
+                        ],
                         "type": "string",
-                        "description": "Cabin class (economy, business)",
+                        "description": "Cabin class",
                         "name": "cabin_class",
                         "in": "query"
                     },
                     {
                         "type": "integer",
-                        "description": "Minimum price (IDR)",
+                        "description": "Also search airports within this radius (km) of origin and destination",
+                        "name": "nearby_km",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Add self-transfer connections built from separately ticketed flights via hub airports",
+                        "name": "self_transfer",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include the facets block (default true)",
+                        "name": "facets",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Display currency (e.g. SGD, MYR, USD); min_price and max_price are given in it",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Minimum price (IDR, or currency when set)",
                         "name": "min_price",
                         "in": "query"
                     },
                     {
-                        "type": "integer",
-                        "description": "Maximum price (IDR)",
+                        "type": "number",
+                        "description": "Maximum price (IDR, or currency when set)",
                         "name": "max_price",
                         "in": "query"
                     },
+                    {
+                        "enum": [
+                            "per_person",
+                            "total"
+                        ],
+                        "type": "string",
+                        "description": "Price the price filters and sorting use: per passenger (default) or the party total",
+                        "name": "price_basis",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
                         "description": "Maximum stops",
@@ -86,6 +217,30 @@ const docTemplate = `{
                         "name": "max_duration",
                         "in": "query"
                     },
+                    {
+                        "type": "integer",
+                        "description": "Maximum minutes of any single layover",
+                        "name": "max_layover",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimum minutes of any single layover",
+                        "name": "min_connection",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Airport or city codes not to connect in (CSV or repeated), e.g. SUB,UPG",
+                        "name": "exclude_connections",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only flights whose fare includes a checked bag",
+                        "name": "checked_bag_included",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
                         "description": "Airline codes (CSV or repeated), e.g. GA,ID",
@@ -130,6 +285,60 @@ const docTemplate = `{
                         "description": "Sort option",
                         "name": "sort_by",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Return date (YYYY-MM-DD), enables round-trip search",
+                        "name": "return_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum stops on the return leg",
+                        "name": "return_max_stops",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Return leg earliest departure time (HH:MM)",
+                        "name": "return_earliest_departure",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Return leg latest departure time (HH:MM)",
+                        "name": "return_latest_departure",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Return leg earliest arrival time (HH:MM)",
+                        "name": "return_earliest_arrival",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Return leg latest arrival time (HH:MM)",
+                        "name": "return_latest_arrival",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Add a cheapest-fare grid for ±N days around the dates (max 3)",
+                        "name": "flex_days",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Return flights in pages of this size (max 200, one-way only)",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "page.next_cursor of the previous page; repeat the other parameters unchanged",
+                        "name": "cursor",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -151,6 +360,12 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "410": {
+                        "description": "Cursor expired",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -159,118 +374,1054 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "domain.Flight": {
-            "type": "object",
-            "properties": {
-                "aircraft": {
-                    "type": "string"
-                },
-                "airline": {
-                    "type": "string"
-                },
-                "airlineCode": {
-                    "type": "string"
-                },
-                "amenities": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
-                },
-                "arrivalTime": {
-                    "type": "string"
-                },
-                "availableSeats": {
-                    "type": "integer"
-                },
-                "baggage": {
-                    "type": "string"
-                },
-                "departureTime": {
-                    "type": "string"
-                },
-                "destination": {
-                    "type": "string"
-                },
-                "durationMin": {
-                    "type": "integer"
-                },
-                "flightCode": {
-                    "type": "string"
-                },
-                "origin": {
-                    "type": "string"
-                },
-                "priceIDR": {
-                    "type": "integer",
-                    "format": "int64"
-                },
-                "stops": {
-                    "type": "integer"
-                }
-            }
-        },
-        "domain.FlightSearchResponse": {
-            "type": "object",
-            "properties": {
-                "flights": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/domain.Flight"
-                    }
-                },
-                "metadata": {
-                    "$ref": "#/definitions/domain.Metadata"
-                },
-                "search_criteria": {
-                    "$ref": "#/definitions/domain.SearchCriteria"
-                }
-            }
         },
-        "domain.Metadata": {
-            "type": "object",
-            "properties": {
-                "cache_hit": {
-                    "type": "boolean"
-                },
-                "providers_failed": {
-                    "type": "integer"
-                },
-                "providers_queried": {
-                    "type": "integer"
-                },
-                "providers_succeeded": {
-                    "type": "integer"
-                },
-                "search_time_ms": {
-                    "type": "integer"
-                },
-                "total_results": {
-                    "type": "integer"
-                }
-            }
-        },
-        "domain.SearchCriteria": {
-            "type": "object",
-            "properties": {
-                "cabin_class": {
-                    "type": "string"
-                },
-                "departure_date": {
-                    "description": "YYYY-MM-DD",
-                    "type": "string"
+        "/search/multi-city": {
+            "get": {
+                "description": "Searches every leg in parallel and combines one flight per leg into ranked itineraries. Consecutive flights must leave at least min_gap minutes between arrival and the next departure. Filters apply per leg; min_price and max_price apply to the itinerary total.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Flights"
+                ],
+                "summary": "Search multi-city itineraries",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Legs in travel order as ORIGIN:DESTINATION:YYYY-MM-DD (CSV or repeated), e.g. CGK:DPS:2025-12-15,DPS:SUB:2025-12-17",
+                        "name": "legs",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimum minutes between a leg's arrival and the next departure (default 120)",
+                        "name": "min_gap",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of passengers; flights with fewer seats left are dropped",
+                        "name": "passengers",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "economy",
+                            "premium_economy",
+                            "business",
+                            "first"
+                        ],
+                        "type": "string",
+                        "description": "Cabin class",
+                        "name": "cabin_class",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Display currency (e.g. SGD, MYR, USD); min_price and max_price are given in it",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Minimum itinerary total (IDR, or currency when set)",
+                        "name": "min_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Maximum itinerary total (IDR, or currency when set)",
+                        "name": "max_price",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "per_person",
+                            "total"
+                        ],
+                        "type": "string",
+                        "description": "Price the price filters use: per passenger (default) or the party total",
+                        "name": "price_basis",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum stops per leg",
+                        "name": "max_stops",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum duration per leg (minutes)",
+                        "name": "max_duration",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum minutes of any single layover",
+                        "name": "max_layover",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimum minutes of any single layover",
+                        "name": "min_connection",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Airport or city codes not to connect in (CSV or repeated), e.g. SUB,UPG",
+                        "name": "exclude_connections",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only flights whose fare includes a checked bag",
+                        "name": "checked_bag_included",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Airline codes (CSV or repeated), e.g. GA,ID",
+                        "name": "airlines",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "price_asc",
+                            "price_desc",
+                            "duration_asc",
+                            "best_value"
+                        ],
+                        "type": "string",
+                        "description": "Sort option",
+                        "name": "sort_by",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.MultiCitySearchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/search/stream": {
+            "get": {
+                "description": "Same parameters as a one-way /search; return_date, flex_days, page_size and cursor are rejected. Emits a \"provider\" Server-Sent Event as soon as each provider answers, carrying that provider's filtered and sorted flights plus running metadata, then a \"complete\" event with the merged, globally sorted response.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "Flights"
+                ],
+                "summary": "Stream flight search results",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Origin airport or city code (e.g. CGK, JKT)",
+                        "name": "origin",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Destination airport or city code (e.g. DPS)",
+                        "name": "destination",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Departure date (YYYY-MM-DD)",
+                        "name": "departure_date",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of passengers; flights with fewer seats left are dropped",
+                        "name": "passengers",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "economy",
+                            "premium_economy",
+                            "business",
+                            "first"
+                        ],
+                        "type": "string",
+                        "description": "Cabin class",
+                        "name": "cabin_class",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Also search airports within this radius (km) of origin and destination",
+                        "name": "nearby_km",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "price_asc",
+                            "price_desc",
+                            "duration_asc",
+                            "duration_desc",
+                            "departure_asc",
+                            "arrival_asc",
+                            "best_value"
+                        ],
+                        "type": "string",
+                        "description": "Sort option",
+                        "name": "sort_by",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "provider event; the complete event carries domain.FlightSearchResponse",
+                        "schema": {
+                            "$ref": "#/definitions/domain.SearchStreamEvent"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "domain.AirlineFacet": {
+            "type": "object",
+            "properties": {
+                "airline": {
+                    "type": "string"
+                },
+                "airline_code": {
+                    "type": "string"
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "lowest_price_idr": {
+                    "type": "integer"
+                }
+            }
+        },
+        "domain.Baggage": {
+            "type": "object",
+            "properties": {
+                "cabinKg": {
+                    "type": "integer"
+                },
+                "cabinPieces": {
+                    "type": "integer"
+                },
+                "checkedIncluded": {
+                    "type": "boolean"
+                },
+                "checkedKg": {
+                    "type": "integer"
+                },
+                "checkedPieces": {
+                    "type": "integer"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "parsed": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "domain.CalendarDay": {
+            "type": "object",
+            "properties": {
+                "airline": {
+                    "type": "string"
+                },
+                "airline_code": {
+                    "type": "string"
+                },
+                "available": {
+                    "type": "boolean"
+                },
+                "cheapest_direct_price_idr": {
+                    "type": "integer"
+                },
+                "cheapest_price_idr": {
+                    "type": "integer"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "direct_available": {
+                    "type": "boolean"
+                },
+                "failed_providers": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "incomplete": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "domain.CalendarResponse": {
+            "type": "object",
+            "properties": {
+                "days": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.CalendarDay"
+                    }
+                },
+                "destination": {
+                    "type": "string"
+                },
+                "incomplete_days": {
+                    "type": "integer"
+                },
+                "month": {
+                    "description": "YYYY-MM",
+                    "type": "string"
+                },
+                "origin": {
+                    "type": "string"
+                },
+                "search_time_ms": {
+                    "type": "integer"
+                }
+            }
+        },
+        "domain.DateFare": {
+            "type": "object",
+            "properties": {
+                "airline_code": {
+                    "type": "string"
+                },
+                "available": {
+                    "type": "boolean"
+                },
+                "cheapest_price_idr": {
+                    "type": "integer"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "flight_code": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.DateMatrixCell": {
+            "type": "object",
+            "properties": {
+                "available": {
+                    "type": "boolean"
+                },
+                "cheapest_total_idr": {
+                    "type": "integer"
+                },
+                "departure_date": {
+                    "type": "string"
+                },
+                "return_date": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.DurationFacet": {
+            "type": "object",
+            "properties": {
+                "max_minutes": {
+                    "type": "integer"
+                },
+                "min_minutes": {
+                    "type": "integer"
+                }
+            }
+        },
+        "domain.Facets": {
+            "type": "object",
+            "properties": {
+                "airlines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.AirlineFacet"
+                    }
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "departure_hours": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.HourFacet"
+                    }
+                },
+                "duration": {
+                    "$ref": "#/definitions/domain.DurationFacet"
+                },
+                "price": {
+                    "$ref": "#/definitions/domain.PriceFacet"
+                },
+                "stops": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.StopsFacet"
+                    }
+                }
+            }
+        },
+        "domain.Fare": {
+            "type": "object",
+            "properties": {
+                "baseIDR": {
+                    "type": "integer",
+                    "format": "int64"
+                },
+                "estimated": {
+                    "type": "boolean"
+                },
+                "feesIDR": {
+                    "type": "integer",
+                    "format": "int64"
+                },
+                "grandTotalIDR": {
+                    "type": "integer",
+                    "format": "int64"
+                },
+                "passengers": {
+                    "type": "integer"
+                },
+                "taxesIDR": {
+                    "type": "integer",
+                    "format": "int64"
+                },
+                "totalIDR": {
+                    "type": "integer",
+                    "format": "int64"
+                }
+            }
+        },
+        "domain.Flight": {
+            "type": "object",
+            "properties": {
+                "aircraft": {
+                    "type": "string"
+                },
+                "airline": {
+                    "type": "string"
+                },
+                "airlineCode": {
+                    "type": "string"
+                },
+                "alternativeOffers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.Offer"
+                    }
+                },
+                "amenities": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "arrivalTime": {
+                    "type": "string"
+                },
+                "availableSeats": {
+                    "type": "integer"
+                },
+                "baggage": {
+                    "$ref": "#/definitions/domain.Baggage"
+                },
+                "cabin": {
+                    "description": "normalized, empty when the provider gave none",
+                    "type": "string"
+                },
+                "departureTime": {
+                    "type": "string"
+                },
+                "destination": {
+                    "type": "string"
+                },
+                "displayAmount": {
+                    "description": "DisplayAmount is PriceIDR in the requested DisplayCurrency, rounded to\nits minor unit; empty when no currency was requested.",
+                    "type": "string"
+                },
+                "displayCurrency": {
+                    "type": "string"
+                },
+                "durationMin": {
+                    "type": "integer"
+                },
+                "fare": {
+                    "$ref": "#/definitions/domain.Fare"
+                },
+                "flightCode": {
+                    "type": "string"
+                },
+                "flyingMin": {
+                    "description": "DurationMin minus the layovers",
+                    "type": "integer"
+                },
+                "fxrate": {
+                    "type": "string"
+                },
+                "groundMin": {
+                    "description": "sum of the layovers",
+                    "type": "integer"
+                },
+                "layovers": {
+                    "description": "one per connection, in order",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.Layover"
+                    }
+                },
+                "legs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.Flight"
+                    }
+                },
+                "origin": {
+                    "type": "string"
+                },
+                "originalAmount": {
+                    "description": "OriginalAmount and OriginalCurrency are the fare as quoted by the\nprovider; FXRate is the IDR per unit it was converted with.",
+                    "type": "string"
+                },
+                "originalCurrency": {
+                    "type": "string"
+                },
+                "priceIDR": {
+                    "description": "per passenger, equal to Fare.TotalIDR",
+                    "type": "integer",
+                    "format": "int64"
+                },
+                "provider": {
+                    "description": "Provider is the source that sold this offer. AlternativeOffers lists the\nother sources selling the same operating flight, cheapest first.",
+                    "type": "string"
+                },
+                "segments": {
+                    "description": "in flying order, empty when the stops are unknown",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.Segment"
+                    }
+                },
+                "selfTransfer": {
+                    "description": "SelfTransfer flights are connections built by the aggregator from\nseparately ticketed Legs: bags are not checked through and a missed\nconnection is not protected.",
+                    "type": "boolean"
+                },
+                "stops": {
+                    "type": "integer"
+                }
+            }
+        },
+        "domain.FlightSearchResponse": {
+            "type": "object",
+            "properties": {
+                "date_grid": {
+                    "description": "flex_days only",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.DateFare"
+                    }
+                },
+                "date_matrix": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.DateMatrixCell"
+                    }
+                },
+                "facets": {
+                    "$ref": "#/definitions/domain.Facets"
+                },
+                "flights": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.Flight"
+                    }
+                },
+                "metadata": {
+                    "$ref": "#/definitions/domain.Metadata"
+                },
+                "page": {
+                    "description": "page_size only",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/domain.PageInfo"
+                        }
+                    ]
+                },
+                "return_date_grid": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.DateFare"
+                    }
+                },
+                "return_flights": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.Flight"
+                    }
+                },
+                "return_metadata": {
+                    "description": "round trip only",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/domain.Metadata"
+                        }
+                    ]
+                },
+                "round_trips": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.RoundTrip"
+                    }
+                },
+                "search_criteria": {
+                    "$ref": "#/definitions/domain.SearchCriteria"
+                }
+            }
+        },
+        "domain.HourFacet": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "hour": {
+                    "type": "integer"
+                }
+            }
+        },
+        "domain.Itinerary": {
+            "type": "object",
+            "properties": {
+                "flights": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.Flight"
+                    }
+                },
+                "mixed_carrier": {
+                    "type": "boolean"
+                },
+                "party_total_idr": {
+                    "type": "integer"
+                },
+                "total_display_amount": {
+                    "type": "string"
+                },
+                "total_duration_min": {
+                    "type": "integer"
+                },
+                "total_price_idr": {
+                    "type": "integer"
+                }
+            }
+        },
+        "domain.Layover": {
+            "type": "object",
+            "properties": {
+                "airport": {
+                    "type": "string"
+                },
+                "durationMin": {
+                    "type": "integer"
+                }
+            }
+        },
+        "domain.LegSummary": {
+            "type": "object",
+            "properties": {
+                "metadata": {
+                    "$ref": "#/definitions/domain.Metadata"
+                },
+                "search_criteria": {
+                    "$ref": "#/definitions/domain.SearchCriteria"
+                }
+            }
+        },
+        "domain.Metadata": {
+            "type": "object",
+            "properties": {
+                "cache_hit": {
+                    "type": "boolean"
+                },
+                "coalesced": {
+                    "type": "boolean"
+                },
+                "hedged_requests": {
+                    "type": "integer"
+                },
+                "hedges_won": {
+                    "type": "integer"
+                },
+                "providers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.ProviderReport"
+                    }
+                },
+                "providers_failed": {
+                    "type": "integer"
+                },
+                "providers_queried": {
+                    "type": "integer"
+                },
+                "providers_succeeded": {
+                    "type": "integer"
+                },
+                "providers_timed_out": {
+                    "type": "integer"
+                },
+                "search_time_ms": {
+                    "type": "integer"
+                },
+                "self_transfers": {
+                    "type": "integer"
+                },
+                "skipped_circuit_open": {
+                    "type": "integer"
+                },
+                "stale": {
+                    "type": "boolean"
+                },
+                "total_results": {
+                    "type": "integer"
+                }
+            }
+        },
+        "domain.MultiCitySearchResponse": {
+            "type": "object",
+            "properties": {
+                "itineraries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.Itinerary"
+                    }
+                },
+                "legs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.LegSummary"
+                    }
+                }
+            }
+        },
+        "domain.Offer": {
+            "type": "object",
+            "properties": {
+                "airlineCode": {
+                    "type": "string"
+                },
+                "availableSeats": {
+                    "type": "integer"
+                },
+                "displayAmount": {
+                    "type": "string"
+                },
+                "displayCurrency": {
+                    "type": "string"
+                },
+                "flightCode": {
+                    "type": "string"
+                },
+                "priceIDR": {
+                    "type": "integer",
+                    "format": "int64"
+                },
+                "provider": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.PageInfo": {
+            "type": "object",
+            "properties": {
+                "next_cursor": {
+                    "type": "string"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "snapshot_at": {
+                    "type": "string"
+                },
+                "total_results": {
+                    "type": "integer"
+                }
+            }
+        },
+        "domain.PriceBasis": {
+            "type": "string",
+            "enum": [
+                "per_person",
+                "total"
+            ],
+            "x-enum-varnames": [
+                "PricePerPerson",
+                "PriceTotal"
+            ]
+        },
+        "domain.PriceBucket": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "from_idr": {
+                    "type": "integer"
+                },
+                "to_idr": {
+                    "type": "integer"
+                }
+            }
+        },
+        "domain.PriceFacet": {
+            "type": "object",
+            "properties": {
+                "histogram": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.PriceBucket"
+                    }
+                },
+                "max_idr": {
+                    "type": "integer"
+                },
+                "min_idr": {
+                    "type": "integer"
+                }
+            }
+        },
+        "domain.ProviderReport": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "cached": {
+                    "type": "boolean"
+                },
+                "dropped_invalid": {
+                    "type": "integer"
+                },
+                "dropped_normalization": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "hedged": {
+                    "type": "boolean"
+                },
+                "latency_ms": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "raw_results": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/domain.ProviderStatus"
+                },
+                "unparsed_baggage": {
+                    "type": "integer"
+                }
+            }
+        },
+        "domain.ProviderStatus": {
+            "type": "string",
+            "enum": [
+                "ok",
+                "timeout",
+                "http_error",
+                "decode_error",
+                "business_failure",
+                "circuit_open",
+                "cancelled"
+            ],
+            "x-enum-varnames": [
+                "ProviderStatusOK",
+                "ProviderStatusTimeout",
+                "ProviderStatusHTTPError",
+                "ProviderStatusDecodeError",
+                "ProviderStatusBusinessFailure",
+                "ProviderStatusCircuitOpen",
+                "ProviderStatusCancelled"
+            ]
+        },
+        "domain.RoundTrip": {
+            "type": "object",
+            "properties": {
+                "inbound": {
+                    "$ref": "#/definitions/domain.Flight"
+                },
+                "mixed_carrier": {
+                    "type": "boolean"
+                },
+                "outbound": {
+                    "$ref": "#/definitions/domain.Flight"
+                },
+                "party_total_idr": {
+                    "type": "integer"
+                },
+                "total_display_amount": {
+                    "type": "string"
+                },
+                "total_price_idr": {
+                    "type": "integer"
+                }
+            }
+        },
+        "domain.SearchCriteria": {
+            "type": "object",
+            "properties": {
+                "cabin_class": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "departure_date": {
+                    "description": "YYYY-MM-DD",
+                    "type": "string"
                 },
                 "destination": {
                     "type": "string"
                 },
+                "destination_airports": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "origin": {
                     "type": "string"
                 },
+                "origin_airports": {
+                    "description": "concrete airports searched for city codes and nearby_km",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "passengers": {
                     "type": "integer"
+                },
+                "price_basis": {
+                    "$ref": "#/definitions/domain.PriceBasis"
+                },
+                "return_date": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.SearchStreamEvent": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "flights": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.Flight"
+                    }
+                },
+                "metadata": {
+                    "$ref": "#/definitions/domain.Metadata"
+                },
+                "provider": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.Segment": {
+            "type": "object",
+            "properties": {
+                "arrivalTime": {
+                    "type": "string"
+                },
+                "departureTime": {
+                    "type": "string"
+                },
+                "destination": {
+                    "type": "string"
+                },
+                "durationMin": {
+                    "type": "integer"
+                },
+                "flightCode": {
+                    "type": "string"
+                },
+                "origin": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.StopsFacet": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "lowest_price_idr": {
+                    "type": "integer"
+                },
+                "stops": {
+                    "type": "integer"
                 }
             }
         }
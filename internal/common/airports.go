@@ -0,0 +1,91 @@
+package common
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// Airport is a reference entry used to expand city codes and nearby-airport
+// searches.
+type Airport struct {
+	Code string
+	City string
+	Lat  float64
+	Lon  float64
+}
+
+var airports = map[string]Airport{
+	"CGK": {Code: "CGK", City: "Jakarta", Lat: -6.1256, Lon: 106.6559},
+	"HLP": {Code: "HLP", City: "Jakarta", Lat: -6.2666, Lon: 106.8911},
+	"BDO": {Code: "BDO", City: "Bandung", Lat: -6.9006, Lon: 107.5764},
+	"KJT": {Code: "KJT", City: "Majalengka", Lat: -6.6483, Lon: 108.1664},
+	"SRG": {Code: "SRG", City: "Semarang", Lat: -6.9727, Lon: 110.3750},
+	"SOC": {Code: "SOC", City: "Solo", Lat: -7.5161, Lon: 110.7569},
+	"JOG": {Code: "JOG", City: "Yogyakarta", Lat: -7.7882, Lon: 110.4318},
+	"YIA": {Code: "YIA", City: "Yogyakarta", Lat: -7.9007, Lon: 110.0573},
+	"SUB": {Code: "SUB", City: "Surabaya", Lat: -7.3798, Lon: 112.7868},
+	"BWX": {Code: "BWX", City: "Banyuwangi", Lat: -8.3101, Lon: 114.3401},
+	"DPS": {Code: "DPS", City: "Denpasar", Lat: -8.7482, Lon: 115.1675},
+	"LOP": {Code: "LOP", City: "Lombok", Lat: -8.7573, Lon: 116.2767},
+	"UPG": {Code: "UPG", City: "Makassar", Lat: -5.0617, Lon: 119.5540},
+	"BPN": {Code: "BPN", City: "Balikpapan", Lat: -1.2683, Lon: 116.8945},
+	"KNO": {Code: "KNO", City: "Medan", Lat: 3.6422, Lon: 98.8853},
+}
+
+// metroCodes maps city codes that are not airports to the airports serving
+// the city.
+var metroCodes = map[string][]string{
+	"JKT": {"CGK", "HLP"},
+}
+
+// ExpandAirport returns the airports a search from or to code should cover:
+// the airports of a city code (or the code itself), plus every known airport
+// within nearbyKM of them when nearbyKM > 0. The result is sorted.
+func ExpandAirport(code string, nearbyKM int) []string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+
+	base, ok := metroCodes[code]
+	if !ok {
+		base = []string{code}
+	}
+
+	set := map[string]struct{}{}
+	for _, c := range base {
+		set[c] = struct{}{}
+	}
+
+	if nearbyKM > 0 {
+		for _, c := range base {
+			from, ok := airports[c]
+			if !ok {
+				continue
+			}
+			for _, a := range airports {
+				if distanceKM(from, a) <= float64(nearbyKM) {
+					set[a.Code] = struct{}{}
+				}
+			}
+		}
+	}
+
+	out := make([]string, 0, len(set))
+	for c := range set {
+		out = append(out, c)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// distanceKM is the great-circle distance between two airports.
+func distanceKM(a, b Airport) float64 {
+	const earthRadiusKM = 6371.0
+
+	lat1, lat2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dLat := lat2 - lat1
+	dLon := (b.Lon - a.Lon) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKM * math.Asin(math.Sqrt(h))
+}
@@ -2,7 +2,10 @@ package common
 
 import (
 	"bookcabin/internal/domain"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"math/big"
 	"sort"
 	"strconv"
 	"strings"
@@ -35,29 +38,142 @@ func ParseFlexibleTime(value string) (time.Time, error) {
 	return time.Time{}, errors.New("unsupported time format: " + value)
 }
 
-// Normalize price to IDR (supports string or numeric)
-func ParsePriceToIDR(value interface{}, currency string) (int64, error) {
+// ParseTravelTime reads an "1h 45m" style duration ("2h", "55m" and no
+// spaces also work) as whole minutes.
+func ParseTravelTime(value string) (int, error) {
+	d, err := time.ParseDuration(strings.ReplaceAll(strings.ToLower(value), " ", ""))
+	if err != nil || d <= 0 || d%time.Minute != 0 {
+		return 0, errors.New("unsupported travel time: " + value)
+	}
+	return int(d.Minutes()), nil
+}
+
+// SplitTravelTime splits a flight's duration into time in the air and on
+// the ground at its layovers.
+func SplitTravelTime(f domain.Flight) (flying, ground int) {
+	for _, l := range f.Layovers {
+		ground += l.DurationMin
+	}
+	return max(f.DurationMin-ground, 0), ground
+}
+
+// RateLookup returns how many IDR one unit of a currency is worth, as a
+// decimal string.
+type RateLookup interface {
+	Rate(currency string) (string, error)
+}
+
+// ParsePriceToIDR converts a provider price (number, json.Number or decimal
+// string, thousands separators allowed) quoted in currency to IDR, rounding
+// half up to whole rupiah. IDR prices need no rates.
+func ParsePriceToIDR(value interface{}, currency string, rates RateLookup) (domain.ConvertedPrice, error) {
+	var raw string
 	switch v := value.(type) {
 	case int:
-		return int64(v), nil
+		raw = strconv.Itoa(v)
+	case int64:
+		raw = strconv.FormatInt(v, 10)
 	case float64:
-		return int64(v), nil
+		raw = strconv.FormatFloat(v, 'f', -1, 64)
+	case json.Number:
+		raw = v.String()
 	case string:
-		clean := strings.ReplaceAll(v, ",", "")
-		clean = strings.TrimSpace(clean)
-		value, err := strconv.Atoi(clean)
-		return int64(value), err
+		raw = strings.TrimSpace(strings.ReplaceAll(v, ",", ""))
 	default:
-		return 0, errors.New("unsupported price format")
+		return domain.ConvertedPrice{}, errors.New("unsupported price format")
 	}
+
+	amount, ok := new(big.Rat).SetString(raw)
+	if !ok || amount.Sign() < 0 {
+		return domain.ConvertedPrice{}, fmt.Errorf("invalid price %q", raw)
+	}
+
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	price := domain.ConvertedPrice{Amount: raw, Currency: currency, Rate: "1"}
+
+	if currency != "IDR" {
+		if currency == "" || rates == nil {
+			return domain.ConvertedPrice{}, fmt.Errorf("%w: %q", domain.ErrUnknownCurrency, currency)
+		}
+		rateStr, err := rates.Rate(currency)
+		if err != nil {
+			return domain.ConvertedPrice{}, err
+		}
+		rate, ok := new(big.Rat).SetString(rateStr)
+		if !ok || rate.Sign() <= 0 {
+			return domain.ConvertedPrice{}, fmt.Errorf("invalid %s rate %q", currency, rateStr)
+		}
+		amount.Mul(amount, rate)
+		price.Rate = rateStr
+	}
+
+	price.IDR = roundHalfUp(amount)
+	return price, nil
 }
 
-func SortFlights(flights []domain.Flight, sortBy string) {
+// currencyMinorUnits is the number of decimals each currency is shown with;
+// currencies not listed use 2.
+var currencyMinorUnits = map[string]int{
+	"IDR": 0,
+	"JPY": 0,
+	"KRW": 0,
+	"VND": 0,
+}
+
+// ConvertFromIDR converts an IDR amount to currency, rounded half up to the
+// currency's minor unit, and returns it as a decimal string.
+func ConvertFromIDR(idr int64, currency string, rates RateLookup) (string, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "IDR" {
+		return strconv.FormatInt(idr, 10), nil
+	}
+	if currency == "" || rates == nil {
+		return "", fmt.Errorf("%w: %q", domain.ErrUnknownCurrency, currency)
+	}
+
+	rateStr, err := rates.Rate(currency)
+	if err != nil {
+		return "", err
+	}
+	rate, ok := new(big.Rat).SetString(rateStr)
+	if !ok || rate.Sign() <= 0 {
+		return "", fmt.Errorf("invalid %s rate %q", currency, rateStr)
+	}
+
+	minor, ok := currencyMinorUnits[currency]
+	if !ok {
+		minor = 2
+	}
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(minor)), nil)
+
+	amount := new(big.Rat).SetInt64(idr)
+	amount.Quo(amount, rate)
+	amount.Mul(amount, new(big.Rat).SetInt(scale))
+
+	units := big.NewInt(roundHalfUp(amount))
+	return new(big.Rat).SetFrac(units, scale).FloatString(minor), nil
+}
+
+// roundHalfUp rounds a non-negative rational to the nearest integer.
+func roundHalfUp(r *big.Rat) int64 {
+	q, m := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	if m.Lsh(m, 1).Cmp(r.Denom()) >= 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	return q.Int64()
+}
+
+// SortFlights orders flights by sortBy; prices are compared on basis
+// (per passenger or the party total).
+func SortFlights(flights []domain.Flight, sortBy string, basis domain.PriceBasis) {
+	price := func(i int) int64 { return FlightPrice(flights[i], basis) }
+	score := func(i int) int64 { return priceScore(price(i), flights[i]) }
+
 	switch strings.ToLower(sortBy) {
 	case "price_asc":
-		sort.Slice(flights, func(i, j int) bool { return flights[i].PriceIDR < flights[j].PriceIDR })
+		sort.Slice(flights, func(i, j int) bool { return price(i) < price(j) })
 	case "price_desc":
-		sort.Slice(flights, func(i, j int) bool { return flights[i].PriceIDR > flights[j].PriceIDR })
+		sort.Slice(flights, func(i, j int) bool { return price(i) > price(j) })
 	case "duration_asc":
 		sort.Slice(flights, func(i, j int) bool { return flights[i].DurationMin < flights[j].DurationMin })
 	case "duration_desc":
@@ -67,21 +183,91 @@ func SortFlights(flights []domain.Flight, sortBy string) {
 	case "arrival_asc":
 		sort.Slice(flights, func(i, j int) bool { return flights[i].ArrivalTime.Before(flights[j].ArrivalTime) })
 	case "best_value":
-		sort.Slice(flights, func(i, j int) bool { return bestValueScore(flights[i]) < bestValueScore(flights[j]) })
+		sort.Slice(flights, func(i, j int) bool { return score(i) < score(j) })
 	default:
 		// base value default
-		sort.Slice(flights, func(i, j int) bool { return bestValueScore(flights[i]) < bestValueScore(flights[j]) })
+		sort.Slice(flights, func(i, j int) bool { return score(i) < score(j) })
+	}
+}
+
+// SortRoundTrips orders round trips by total price, total duration or the
+// combined best value score of both legs (default).
+func SortRoundTrips(trips []domain.RoundTrip, sortBy string) {
+	switch strings.ToLower(sortBy) {
+	case "price_asc":
+		sort.Slice(trips, func(i, j int) bool { return trips[i].TotalPriceIDR < trips[j].TotalPriceIDR })
+	case "price_desc":
+		sort.Slice(trips, func(i, j int) bool { return trips[i].TotalPriceIDR > trips[j].TotalPriceIDR })
+	case "duration_asc":
+		sort.Slice(trips, func(i, j int) bool { return roundTripDuration(trips[i]) < roundTripDuration(trips[j]) })
+	default:
+		sort.Slice(trips, func(i, j int) bool { return roundTripScore(trips[i]) < roundTripScore(trips[j]) })
+	}
+}
+
+func roundTripDuration(t domain.RoundTrip) int {
+	return t.Outbound.DurationMin + t.Inbound.DurationMin
+}
+
+func roundTripScore(t domain.RoundTrip) int64 {
+	return bestValueScore(t.Outbound) + bestValueScore(t.Inbound)
+}
+
+// SortItineraries orders multi-city itineraries by total price, total flying
+// time or the summed best value score of their flights (default).
+func SortItineraries(its []domain.Itinerary, sortBy string) {
+	switch strings.ToLower(sortBy) {
+	case "price_asc":
+		sort.SliceStable(its, func(i, j int) bool { return its[i].TotalPriceIDR < its[j].TotalPriceIDR })
+	case "price_desc":
+		sort.SliceStable(its, func(i, j int) bool { return its[i].TotalPriceIDR > its[j].TotalPriceIDR })
+	case "duration_asc":
+		sort.SliceStable(its, func(i, j int) bool { return its[i].TotalDurationMin < its[j].TotalDurationMin })
+	default:
+		sort.SliceStable(its, func(i, j int) bool { return itineraryScore(its[i]) < itineraryScore(its[j]) })
+	}
+}
+
+func itineraryScore(it domain.Itinerary) int64 {
+	var score int64
+	for _, f := range it.Flights {
+		score += bestValueScore(f)
 	}
+	return score
 }
 
 func bestValueScore(f domain.Flight) int64 {
+	return priceScore(f.PriceIDR, f)
+}
+
+func priceScore(price int64, f domain.Flight) int64 {
 	score := int64(0)
-	score += f.PriceIDR / 10000
+	score += price / 10000
 	score += int64(f.DurationMin)
 	score += int64(f.Stops * 100)
 	return score
 }
 
+var cabinAlias = map[string]string{
+	"ECONOMY":         domain.CabinEconomy,
+	"ECO":             domain.CabinEconomy,
+	"Y":               domain.CabinEconomy,
+	"PREMIUM ECONOMY": domain.CabinPremiumEconomy,
+	"PREMIUM_ECONOMY": domain.CabinPremiumEconomy,
+	"W":               domain.CabinPremiumEconomy,
+	"BUSINESS":        domain.CabinBusiness,
+	"C":               domain.CabinBusiness,
+	"J":               domain.CabinBusiness,
+	"FIRST":           domain.CabinFirst,
+	"F":               domain.CabinFirst,
+}
+
+// NormalizeCabin maps a provider cabin name or IATA cabin code to a
+// normalized cabin, or "" if it is not recognised.
+func NormalizeCabin(cabin string) string {
+	return cabinAlias[strings.ToUpper(strings.TrimSpace(cabin))]
+}
+
 var airlineAlias = map[string]string{
 	"GARUDA":           "GA",
 	"GARUDA INDONESIA": "GA",
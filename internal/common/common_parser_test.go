@@ -0,0 +1,149 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"bookcabin/internal/domain"
+)
+
+// testRates serves fixed IDR rates.
+type testRates map[string]string
+
+func (r testRates) Rate(currency string) (string, error) {
+	rate, ok := r[currency]
+	if !ok {
+		return "", errors.New("no rate for " + currency)
+	}
+	return rate, nil
+}
+
+var fixedRates = testRates{
+	"USD": "16250.00",
+	"SGD": "12000",
+	"JPY": "100",
+	"BAD": "abc",
+	"NIL": "0",
+}
+
+func TestParsePriceToIDR(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    interface{}
+		currency string
+		want     int64
+		wantErr  bool
+	}{
+		{name: "int IDR", value: 650000, currency: "IDR", want: 650000},
+		{name: "int64 IDR", value: int64(650000), currency: "idr", want: 650000},
+		{name: "thousands separators", value: "1,250,000", currency: "IDR", want: 1250000},
+		{name: "json.Number", value: json.Number("12.34"), currency: "SGD", want: 148080},
+		{name: "half rupiah rounds up", value: 79.95, currency: "USD", want: 1299188},
+		{name: "IDR decimals half up", value: "100.5", currency: "IDR", want: 101},
+		{name: "IDR decimals below half", value: "100.49", currency: "IDR", want: 100},
+		{name: "zero-decimal currency", value: "1000", currency: "JPY", want: 100000},
+		{name: "zero", value: 0, currency: "IDR", want: 0},
+		{name: "negative", value: "-5", currency: "IDR", wantErr: true},
+		{name: "not a number", value: "12abc", currency: "IDR", wantErr: true},
+		{name: "empty", value: "", currency: "IDR", wantErr: true},
+		{name: "unsupported type", value: true, currency: "IDR", wantErr: true},
+		{name: "no rate", value: 10, currency: "EUR", wantErr: true},
+		{name: "invalid rate", value: 10, currency: "BAD", wantErr: true},
+		{name: "zero rate", value: 10, currency: "NIL", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePriceToIDR(tt.value, tt.currency, fixedRates)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParsePriceToIDR(%v, %s) = %d, want an error", tt.value, tt.currency, got.IDR)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.IDR != tt.want {
+				t.Fatalf("ParsePriceToIDR(%v, %s) = %d, want %d", tt.value, tt.currency, got.IDR, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePriceToIDRWithoutRates(t *testing.T) {
+	if got, err := ParsePriceToIDR(500000, "IDR", nil); err != nil || got.IDR != 500000 {
+		t.Fatalf("IDR without rates = %d, %v", got.IDR, err)
+	}
+	if _, err := ParsePriceToIDR(10, "USD", nil); !errors.Is(err, domain.ErrUnknownCurrency) {
+		t.Fatalf("err = %v, want %v", err, domain.ErrUnknownCurrency)
+	}
+}
+
+func TestConvertFromIDR(t *testing.T) {
+	tests := []struct {
+		name     string
+		idr      int64
+		currency string
+		want     string
+		wantErr  bool
+	}{
+		{name: "IDR unchanged", idr: 1200000, currency: "IDR", want: "1200000"},
+		{name: "two decimals", idr: 1200000, currency: "SGD", want: "100.00"},
+		{name: "half cent rounds up", idr: 1200060, currency: "SGD", want: "100.01"},
+		{name: "below half cent", idr: 1200059, currency: "SGD", want: "100.00"},
+		{name: "lowercase currency", idr: 1200000, currency: "sgd", want: "100.00"},
+		{name: "zero", idr: 0, currency: "SGD", want: "0.00"},
+		{name: "zero-decimal half up", idr: 1050, currency: "JPY", want: "11"},
+		{name: "zero-decimal below half", idr: 1049, currency: "JPY", want: "10"},
+		{name: "empty currency", idr: 100, currency: "", wantErr: true},
+		{name: "no rate", idr: 100, currency: "EUR", wantErr: true},
+		{name: "invalid rate", idr: 100, currency: "BAD", wantErr: true},
+		{name: "zero rate", idr: 100, currency: "NIL", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ConvertFromIDR(tt.idr, tt.currency, fixedRates)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ConvertFromIDR(%d, %s) = %q, want an error", tt.idr, tt.currency, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("ConvertFromIDR(%d, %s) = %q, want %q", tt.idr, tt.currency, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTravelTime(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int
+		wantErr bool
+	}{
+		{in: "1h 45m", want: 105},
+		{in: "2h", want: 120},
+		{in: "55m", want: 55},
+		{in: "1h45m", want: 105},
+		{in: "1H 05M", want: 65},
+		{in: "", wantErr: true},
+		{in: "0m", wantErr: true},
+		{in: "-1h", wantErr: true},
+		{in: "90s", wantErr: true},
+		{in: "1.5 hours", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseTravelTime(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseTravelTime(%q) = %d, %v; want %d (error %t)", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package common
+
+import (
+	"math/big"
+
+	"bookcabin/internal/domain"
+)
+
+// estimatedTaxRate is the share of a fare assumed to be taxes (11% VAT on
+// the base fare) when a provider only quotes a total.
+var estimatedTaxRate = big.NewRat(111, 100)
+
+// NewFare builds a per-passenger fare from a provider's base, taxes and
+// total; whatever the total holds beyond base and taxes is counted as fees.
+// A rounding shortfall (after currency conversion) is taken off the taxes.
+func NewFare(base, taxes, total int64, passengers int) domain.Fare {
+	fees := total - base - taxes
+	if fees < 0 {
+		taxes, fees = max(taxes+fees, 0), 0
+	}
+	return partyFare(domain.Fare{
+		BaseIDR:  base,
+		TaxesIDR: taxes,
+		FeesIDR:  fees,
+		TotalIDR: total,
+	}, passengers)
+}
+
+// EstimateFare splits a per-passenger total into base and taxes at the
+// standard VAT rate and marks the fare as estimated.
+func EstimateFare(total int64, passengers int) domain.Fare {
+	base := roundHalfUp(new(big.Rat).Quo(new(big.Rat).SetInt64(total), estimatedTaxRate))
+	return partyFare(domain.Fare{
+		BaseIDR:   base,
+		TaxesIDR:  total - base,
+		TotalIDR:  total,
+		Estimated: true,
+	}, passengers)
+}
+
+// AddFares sums the fares of separately ticketed flights for the same party.
+func AddFares(a, b domain.Fare) domain.Fare {
+	return domain.Fare{
+		BaseIDR:       a.BaseIDR + b.BaseIDR,
+		TaxesIDR:      a.TaxesIDR + b.TaxesIDR,
+		FeesIDR:       a.FeesIDR + b.FeesIDR,
+		TotalIDR:      a.TotalIDR + b.TotalIDR,
+		Passengers:    a.Passengers,
+		GrandTotalIDR: a.GrandTotalIDR + b.GrandTotalIDR,
+		Estimated:     a.Estimated || b.Estimated,
+	}
+}
+
+func partyFare(f domain.Fare, passengers int) domain.Fare {
+	f.Passengers = max(passengers, 1)
+	f.GrandTotalIDR = f.TotalIDR * int64(f.Passengers)
+	return f
+}
+
+// FlightPrice is the price of a flight the price filters and sorts use: the
+// per-passenger fare, or the whole party's total for domain.PriceTotal.
+func FlightPrice(f domain.Flight, basis domain.PriceBasis) int64 {
+	if basis == domain.PriceTotal && f.Fare.Passengers > 0 {
+		return f.Fare.GrandTotalIDR
+	}
+	return f.PriceIDR
+}
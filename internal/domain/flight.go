@@ -14,6 +14,22 @@ const (
 	SortBestValue    SortOption = "best_value"
 )
 
+// PriceBasis selects which price the price filters and sorts use.
+type PriceBasis string
+
+const (
+	PricePerPerson PriceBasis = "per_person"
+	PriceTotal     PriceBasis = "total"
+)
+
+// Normalized cabins.
+const (
+	CabinEconomy        = "economy"
+	CabinPremiumEconomy = "premium_economy"
+	CabinBusiness       = "business"
+	CabinFirst          = "first"
+)
+
 type Flight struct {
 	FlightCode     string
 	Airline        string
@@ -23,38 +39,306 @@ type Flight struct {
 	DepartureTime  time.Time
 	ArrivalTime    time.Time
 	DurationMin    int
+	FlyingMin      int // DurationMin minus the layovers
+	GroundMin      int // sum of the layovers
 	Stops          int
-	PriceIDR       int64
+	Segments       []Segment // in flying order, empty when the stops are unknown
+	Layovers       []Layover // one per connection, in order
+	PriceIDR       int64     // per passenger, equal to Fare.TotalIDR
+	Fare           Fare
 	AvailableSeats int
+	Cabin          string // normalized, empty when the provider gave none
 	Aircraft       string
-	Baggage        string
+	Baggage        Baggage
 	Amenities      []string
+
+	// OriginalAmount and OriginalCurrency are the fare as quoted by the
+	// provider; FXRate is the IDR per unit it was converted with.
+	OriginalAmount   string
+	OriginalCurrency string
+	FXRate           string
+
+	// DisplayAmount is PriceIDR in the requested DisplayCurrency, rounded to
+	// its minor unit; empty when no currency was requested.
+	DisplayAmount   string
+	DisplayCurrency string
+
+	// Provider is the source that sold this offer. AlternativeOffers lists the
+	// other sources selling the same operating flight, cheapest first.
+	Provider          string
+	AlternativeOffers []Offer
+
+	// SelfTransfer flights are connections built by the aggregator from
+	// separately ticketed Legs: bags are not checked through and a missed
+	// connection is not protected.
+	SelfTransfer bool
+	Legs         []Flight
+}
+
+// Segment is one flight of an itinerary. Times and duration are zero when
+// the provider only gave the connection airports.
+type Segment struct {
+	FlightCode    string
+	Origin        string
+	Destination   string
+	DepartureTime time.Time
+	ArrivalTime   time.Time
+	DurationMin   int
+}
+
+// Layover is the time spent on the ground between two segments.
+type Layover struct {
+	Airport     string
+	DurationMin int
+}
+
+// Baggage is the allowance per passenger. Pieces and kg are 0 when the
+// provider does not state them. Parsed is false when the provider's text
+// could not be read; Note keeps the text as given.
+type Baggage struct {
+	CabinPieces     int
+	CabinKg         int
+	CheckedPieces   int
+	CheckedKg       int
+	CheckedIncluded bool
+	Parsed          bool
+	Note            string
+}
+
+// Fare is the price breakdown of a flight in IDR. Base, taxes, fees and
+// total are per passenger; GrandTotalIDR is for all Passengers. Estimated is
+// set when the provider only gave a total and the split was derived.
+type Fare struct {
+	BaseIDR       int64
+	TaxesIDR      int64
+	FeesIDR       int64
+	TotalIDR      int64
+	Passengers    int
+	GrandTotalIDR int64
+	Estimated     bool
+}
+
+// ConvertedPrice is a provider fare converted to IDR. Amount and Rate are
+// decimal strings.
+type ConvertedPrice struct {
+	IDR      int64
+	Amount   string
+	Currency string
+	Rate     string
+}
+
+// Offer is another source's price for an already listed flight.
+// DisplayAmount is PriceIDR in DisplayCurrency, like on Flight.
+type Offer struct {
+	Provider       string
+	FlightCode     string
+	AirlineCode    string
+	PriceIDR       int64
+	AvailableSeats int
+
+	DisplayAmount   string
+	DisplayCurrency string
 }
 
 type FlightFilter struct {
-	MinPrice          int64
-	MaxPrice          int64
-	MaxStops          int
-	EarliestDeparture time.Time
-	LatestDeparture   time.Time
-	EarliestArrival   time.Time
-	LatestArrival     time.Time
-	Airlines          []string
-	MaxDuration       int
+	MinPrice           int64
+	MaxPrice           int64
+	MaxStops           int
+	EarliestDeparture  time.Time
+	LatestDeparture    time.Time
+	EarliestArrival    time.Time
+	LatestArrival      time.Time
+	Airlines           []string
+	MaxDuration        int
+	Cabin              string
+	Passengers         int
+	PriceBasis         PriceBasis
+	CheckedBagIncluded bool
+
+	// layovers, in minutes; 0 means no limit
+	MaxLayover          int
+	MinConnection       int
+	ExcludedConnections []string
 }
 
 type FlightSearchResponse struct {
 	SearchCriteria SearchCriteria `json:"search_criteria"`
 	Metadata       Metadata       `json:"metadata"`
 	Flights        []Flight       `json:"flights"`
+	Facets         *Facets        `json:"facets,omitempty"`
+
+	// page_size only
+	Page *PageInfo `json:"page,omitempty"`
+
+	// round trip only
+	ReturnMetadata *Metadata   `json:"return_metadata,omitempty"`
+	ReturnFlights  []Flight    `json:"return_flights,omitempty"`
+	RoundTrips     []RoundTrip `json:"round_trips,omitempty"`
+
+	// flex_days only
+	DateGrid       []DateFare       `json:"date_grid,omitempty"`
+	ReturnDateGrid []DateFare       `json:"return_date_grid,omitempty"`
+	DateMatrix     []DateMatrixCell `json:"date_matrix,omitempty"`
+}
+
+// DateFare is the cheapest fare found for a single departure date.
+type DateFare struct {
+	Date             string `json:"date"`
+	Available        bool   `json:"available"`
+	CheapestPriceIDR int64  `json:"cheapest_price_idr,omitempty"`
+	FlightCode       string `json:"flight_code,omitempty"`
+	AirlineCode      string `json:"airline_code,omitempty"`
+}
+
+// DateMatrixCell is the cheapest round-trip total for a pair of outbound and
+// return dates.
+type DateMatrixCell struct {
+	DepartureDate    string `json:"departure_date"`
+	ReturnDate       string `json:"return_date"`
+	Available        bool   `json:"available"`
+	CheapestTotalIDR int64  `json:"cheapest_total_idr,omitempty"`
+}
+
+// Facets are counts and ranges over every flight on the searched route and
+// date, before the request's filters are applied, for building filter UIs.
+type Facets struct {
+	Count          int            `json:"count"`
+	Price          PriceFacet     `json:"price"`
+	Airlines       []AirlineFacet `json:"airlines"`
+	Stops          []StopsFacet   `json:"stops"`
+	DepartureHours []HourFacet    `json:"departure_hours"`
+	Duration       DurationFacet  `json:"duration"`
+}
+
+// PriceFacet is the price range and a fixed-width histogram of prices.
+type PriceFacet struct {
+	MinIDR    int64         `json:"min_idr"`
+	MaxIDR    int64         `json:"max_idr"`
+	Histogram []PriceBucket `json:"histogram"`
+}
+
+// PriceBucket counts the flights priced in [FromIDR, ToIDR).
+type PriceBucket struct {
+	FromIDR int64 `json:"from_idr"`
+	ToIDR   int64 `json:"to_idr"`
+	Count   int   `json:"count"`
+}
+
+type AirlineFacet struct {
+	AirlineCode    string `json:"airline_code"`
+	Airline        string `json:"airline"`
+	Count          int    `json:"count"`
+	LowestPriceIDR int64  `json:"lowest_price_idr"`
+}
+
+type StopsFacet struct {
+	Stops          int   `json:"stops"`
+	Count          int   `json:"count"`
+	LowestPriceIDR int64 `json:"lowest_price_idr"`
+}
+
+// HourFacet counts the flights departing in a local hour (0-23).
+type HourFacet struct {
+	Hour  int `json:"hour"`
+	Count int `json:"count"`
+}
+
+type DurationFacet struct {
+	MinMinutes int `json:"min_minutes"`
+	MaxMinutes int `json:"max_minutes"`
+}
+
+// PageInfo describes one page of a paginated search. Every page of a search
+// comes from the same snapshot, taken when the first page was requested.
+type PageInfo struct {
+	PageSize     int       `json:"page_size"`
+	TotalResults int       `json:"total_results"`
+	NextCursor   string    `json:"next_cursor,omitempty"`
+	SnapshotAt   time.Time `json:"snapshot_at"`
+}
+
+// RoundTrip is a priced outbound + inbound pair, possibly on different
+// carriers.
+type RoundTrip struct {
+	Outbound      Flight `json:"outbound"`
+	Inbound       Flight `json:"inbound"`
+	TotalPriceIDR int64  `json:"total_price_idr"`
+	PartyTotalIDR int64  `json:"party_total_idr"`
+	MixedCarrier  bool   `json:"mixed_carrier"`
+
+	TotalDisplayAmount string `json:"total_display_amount,omitempty"`
+}
+
+// Itinerary is a priced combination of one flight per multi-city leg.
+type Itinerary struct {
+	Flights          []Flight `json:"flights"`
+	TotalPriceIDR    int64    `json:"total_price_idr"`
+	PartyTotalIDR    int64    `json:"party_total_idr"`
+	TotalDurationMin int      `json:"total_duration_min"`
+	MixedCarrier     bool     `json:"mixed_carrier"`
+
+	TotalDisplayAmount string `json:"total_display_amount,omitempty"`
+}
+
+// MultiCitySearchResponse reports every leg search and the itineraries built
+// from them.
+type MultiCitySearchResponse struct {
+	Legs        []LegSummary `json:"legs"`
+	Itineraries []Itinerary  `json:"itineraries"`
+}
+
+// LegSummary describes how a single multi-city leg was searched.
+type LegSummary struct {
+	SearchCriteria SearchCriteria `json:"search_criteria"`
+	Metadata       Metadata       `json:"metadata"`
+}
+
+// CalendarDay is the lowest fare found for one day of a month calendar.
+// Incomplete days had at least one provider fail, so a cheaper fare may
+// exist.
+type CalendarDay struct {
+	Date                   string   `json:"date"`
+	Available              bool     `json:"available"`
+	CheapestPriceIDR       int64    `json:"cheapest_price_idr,omitempty"`
+	Airline                string   `json:"airline,omitempty"`
+	AirlineCode            string   `json:"airline_code,omitempty"`
+	DirectAvailable        bool     `json:"direct_available"`
+	CheapestDirectPriceIDR int64    `json:"cheapest_direct_price_idr,omitempty"`
+	Incomplete             bool     `json:"incomplete"`
+	FailedProviders        []string `json:"failed_providers,omitempty"`
+}
+
+// CalendarResponse is the lowest fare per day of a month on a route.
+type CalendarResponse struct {
+	Origin         string        `json:"origin"`
+	Destination    string        `json:"destination"`
+	Month          string        `json:"month"` // YYYY-MM
+	IncompleteDays int           `json:"incomplete_days"`
+	SearchTimeMS   int           `json:"search_time_ms"`
+	Days           []CalendarDay `json:"days"`
+}
+
+// SearchStreamEvent is the payload of a per-provider Server-Sent Event.
+type SearchStreamEvent struct {
+	Provider string   `json:"provider"`
+	Error    string   `json:"error,omitempty"`
+	Metadata Metadata `json:"metadata"`
+	Flights  []Flight `json:"flights"`
 }
 
 type SearchCriteria struct {
-	Origin        string `json:"origin"`
-	Destination   string `json:"destination"`
-	DepartureDate string `json:"departure_date"` // YYYY-MM-DD
-	Passengers    int    `json:"passengers"`
-	CabinClass    string `json:"cabin_class"`
+	Origin        string     `json:"origin"`
+	Destination   string     `json:"destination"`
+	DepartureDate string     `json:"departure_date"` // YYYY-MM-DD
+	ReturnDate    string     `json:"return_date,omitempty"`
+	Passengers    int        `json:"passengers"`
+	CabinClass    string     `json:"cabin_class"`
+	Currency      string     `json:"currency,omitempty"`
+	PriceBasis    PriceBasis `json:"price_basis,omitempty"`
+
+	// concrete airports searched for city codes and nearby_km
+	OriginAirports      []string `json:"origin_airports,omitempty"`
+	DestinationAirports []string `json:"destination_airports,omitempty"`
 }
 
 type Metadata struct {
@@ -62,6 +346,46 @@ type Metadata struct {
 	ProvidersQueried   int  `json:"providers_queried"`
 	ProvidersSucceeded int  `json:"providers_succeeded"`
 	ProvidersFailed    int  `json:"providers_failed"`
+	ProvidersTimedOut  int  `json:"providers_timed_out"`
+	SkippedCircuitOpen int  `json:"skipped_circuit_open"`
+	HedgedRequests     int  `json:"hedged_requests"`
+	HedgesWon          int  `json:"hedges_won"`
 	SearchTimeMS       int  `json:"search_time_ms"`
 	CacheHit           bool `json:"cache_hit"`
+	Stale              bool `json:"stale"`
+	Coalesced          bool `json:"coalesced"`
+	SelfTransfers      int  `json:"self_transfers"`
+
+	Providers []ProviderReport `json:"providers"`
+}
+
+type ProviderStatus string
+
+const (
+	ProviderStatusOK              ProviderStatus = "ok"
+	ProviderStatusTimeout         ProviderStatus = "timeout"
+	ProviderStatusHTTPError       ProviderStatus = "http_error"
+	ProviderStatusDecodeError     ProviderStatus = "decode_error"
+	ProviderStatusBusinessFailure ProviderStatus = "business_failure"
+	ProviderStatusCircuitOpen     ProviderStatus = "circuit_open"
+	// the caller went away before the provider answered
+	ProviderStatusCancelled ProviderStatus = "cancelled"
+)
+
+// ProviderReport explains what a single provider contributed to a search.
+// DroppedInvalid counts flights rejected by the aggregator's sanity checks,
+// DroppedNormalization those the adapter could not map and UnparsedBaggage
+// those kept with a baggage allowance it could not read.
+type ProviderReport struct {
+	Name                 string         `json:"name"`
+	Status               ProviderStatus `json:"status"`
+	Cached               bool           `json:"cached"`
+	Error                string         `json:"error,omitempty"`
+	LatencyMS            int            `json:"latency_ms"`
+	Attempts             int            `json:"attempts"`
+	Hedged               bool           `json:"hedged"`
+	RawResults           int            `json:"raw_results"`
+	DroppedInvalid       int            `json:"dropped_invalid"`
+	DroppedNormalization int            `json:"dropped_normalization"`
+	UnparsedBaggage      int            `json:"unparsed_baggage"`
 }
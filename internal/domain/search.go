@@ -1,13 +1,43 @@
 package domain
 
+import "errors"
+
+// Errors providers wrap so the aggregator can tell failure kinds apart.
+var (
+	ErrProviderDecode   = errors.New("provider response could not be decoded")
+	ErrProviderBusiness = errors.New("provider reported a business failure")
+)
+
+// ErrUnknownCurrency is returned when a price is quoted in a currency with no
+// exchange rate.
+var ErrUnknownCurrency = errors.New("unknown currency")
+
+// Errors returned for paginated searches.
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrCursorExpired = errors.New("cursor expired, restart the search")
+)
+
 type SearchRequest struct {
 	Origin        string `json:"origin"`
 	Destination   string `json:"destination"`
 	DepartureDate string `json:"departure_date"`
+	ReturnDate    string `json:"return_date,omitempty"`
 	Passengers    int    `json:"passenger"`
 	CabinClass    string `json:"cabin_class"`
 
-	// filter
+	// NearbyKM also searches every airport within this radius of the origin
+	// and destination airports
+	NearbyKM int `json:"nearby_km,omitempty"`
+
+	// Currency is the display currency, empty for IDR only
+	Currency string `json:"currency,omitempty"`
+
+	// PriceBasis makes the price filters and sorts use the per-person fare
+	// (default) or the total for all passengers
+	PriceBasis PriceBasis `json:"price_basis,omitempty"`
+
+	// filter, prices in IDR
 	MinPrice    int64    `json:"min_price,omitempty"`
 	MaxPrice    int64    `json:"max_price,omitempty"`
 	MaxStops    int      `json:"max_stops,omitempty"`
@@ -18,14 +48,118 @@ type SearchRequest struct {
 	EarliestArr string   `json:"earliest_arrival,omitempty"`
 	LatestArr   string   `json:"latest_arrival,omitempty"`
 
+	// layover filter, minutes; applies to every leg searched
+	MaxLayover         int      `json:"max_layover,omitempty"`
+	MinConnection      int      `json:"min_connection,omitempty"`
+	ExcludeConnections []string `json:"exclude_connections,omitempty"`
+
+	// only flights with a checked bag in the fare
+	CheckedBagIncluded bool `json:"checked_bag_included,omitempty"`
+
+	// return leg filter, only used when ReturnDate is set
+	ReturnMaxStops    int    `json:"return_max_stops,omitempty"`
+	ReturnEarliestDep string `json:"return_earliest_departure,omitempty"`
+	ReturnLatestDep   string `json:"return_latest_departure,omitempty"`
+	ReturnEarliestArr string `json:"return_earliest_arrival,omitempty"`
+	ReturnLatestArr   string `json:"return_latest_arrival,omitempty"`
+
+	// SelfTransfer adds connections built from separately ticketed legs
+	SelfTransfer bool `json:"self_transfer,omitempty"`
+
 	// sort
 	SortBy string `json:"sort_by,omitempty"`
+
+	// SkipFacets leaves out the facets block for lightweight clients
+	SkipFacets bool `json:"skip_facets,omitempty"`
+
+	// pagination; Cursor continues the snapshot of a previous page
+	PageSize int    `json:"page_size,omitempty"`
+	Cursor   string `json:"cursor,omitempty"`
+
+	// FlexDays adds a cheapest-fare grid for ±FlexDays around the dates
+	FlexDays int `json:"flex_days,omitempty"`
+}
+
+// ProviderResult is what a single provider call produced. RawResults counts
+// the flights in the provider payload; DroppedNormalization counts those that
+// could not be mapped to a Flight, UnparsedBaggage those whose baggage text
+// the adapter could not read (the flight is kept).
+type ProviderResult struct {
+	Flights              []Flight
+	Attempts             int
+	RawResults           int
+	DroppedNormalization int
+	UnparsedBaggage      int
 }
 
 type SearchResult struct {
 	Flights            []Flight
 	CacheHit           bool
+	Stale              bool
 	ProvidersQueried   int
 	ProvidersSucceeded int
 	ProvidersFailed    int
+	ProvidersTimedOut  int
+	SkippedCircuitOpen int
+	Providers          []ProviderReport
+	HedgedRequests     int
+	HedgesWon          int
+	Coalesced          bool
+	SelfTransfers      int
+
+	// Facets summarise the flights on the route and date before the
+	// request's filters; nil when skipped
+	Facets *Facets
+
+	// OriginAirports and DestinationAirports are the concrete airports the
+	// requested codes were expanded to
+	OriginAirports      []string
+	DestinationAirports []string
+}
+
+// RoundTripResult holds both leg searches and their priced combinations.
+type RoundTripResult struct {
+	Outbound   SearchResult
+	Inbound    SearchResult
+	RoundTrips []RoundTrip
+}
+
+// Leg is one hop of a multi-city search.
+type Leg struct {
+	Origin        string `json:"origin"`
+	Destination   string `json:"destination"`
+	DepartureDate string `json:"departure_date"`
+}
+
+// MultiCityRequest searches Legs in order. Search carries the passengers,
+// cabin, filters and sort shared by every leg; its price filters apply to the
+// itinerary total. MinGap is the minimum number of minutes between a leg's
+// arrival and the next leg's departure, negative for the configured default.
+type MultiCityRequest struct {
+	Legs   []Leg
+	Search SearchRequest
+	MinGap int
+}
+
+// MultiCityResult holds every leg search and the ranked itineraries.
+type MultiCityResult struct {
+	Legs        []SearchResult
+	Itineraries []Itinerary
+}
+
+// FlexGrid holds the cheapest fares around the requested dates. Inbound and
+// Matrix are only set for round trips.
+type FlexGrid struct {
+	Outbound []DateFare
+	Inbound  []DateFare
+	Matrix   []DateMatrixCell
+}
+
+// ProviderUpdate reports a single provider's answer while a search is still in
+// flight. Result.Flights holds only that provider's flights, already filtered
+// and sorted; the provider counters are running totals.
+type ProviderUpdate struct {
+	Provider string
+	Err      error
+	Result   SearchResult
 }
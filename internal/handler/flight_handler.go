@@ -1,15 +1,28 @@
 package handler
 
 import (
+	"bookcabin/internal/common"
 	"bookcabin/internal/domain"
 	"bookcabin/internal/service"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
 )
 
+const (
+	// maxMultiCityLegs caps how many legs a multi-city search may have.
+	maxMultiCityLegs = 6
+	// maxFlexDays caps flex_days; a round trip searches 2*(2*N+1) dates.
+	maxFlexDays = 3
+	// maxPageSize caps page_size.
+	maxPageSize = 200
+)
+
 type FlightHandler struct {
 	FlightService *service.SearchFlightsUseCase
 }
@@ -27,16 +40,25 @@ func NewFlightHandler(fs *service.SearchFlightsUseCase) FlightHandler {
 // @Accept       json
 // @Produce      json
 //
-// @Param origin query string true "Origin airport code (e.g. CGK)"
-// @Param destination query string true "Destination airport code (e.g. DPS)"
+// @Param origin query string true "Origin airport or city code (e.g. CGK, JKT)"
+// @Param destination query string true "Destination airport or city code (e.g. DPS)"
 // @Param departure_date query string true "Departure date (YYYY-MM-DD)"
-// @Param passengers query int false "Number of passengers"
-// @Param cabin_class query string false "Cabin class (economy, business)"
+// @Param passengers query int false "Number of passengers; flights with fewer seats left are dropped"
+// @Param cabin_class query string false "Cabin class" Enums(economy,premium_economy,business,first)
+// @Param nearby_km query int false "Also search airports within this radius (km) of origin and destination"
+// @Param self_transfer query bool false "Add self-transfer connections built from separately ticketed flights via hub airports"
+// @Param facets query bool false "Include the facets block (default true)"
 //
-// @Param min_price query int false "Minimum price (IDR)"
-// @Param max_price query int false "Maximum price (IDR)"
+// @Param currency query string false "Display currency (e.g. SGD, MYR, USD); min_price and max_price are given in it"
+// @Param min_price query number false "Minimum price (IDR, or currency when set)"
+// @Param max_price query number false "Maximum price (IDR, or currency when set)"
+// @Param price_basis query string false "Price the price filters and sorting use: per passenger (default) or the party total" Enums(per_person,total)
 // @Param max_stops query int false "Maximum stops"
 // @Param max_duration query int false "Maximum duration (minutes)"
+// @Param max_layover query int false "Maximum minutes of any single layover"
+// @Param min_connection query int false "Minimum minutes of any single layover"
+// @Param exclude_connections query string false "Airport or city codes not to connect in (CSV or repeated), e.g. SUB,UPG"
+// @Param checked_bag_included query bool false "Only flights whose fare includes a checked bag"
 // @Param airlines query string false "Airline codes (CSV or repeated), e.g. GA,ID"
 //
 // @Param earliest_departure query string false "Earliest departure time (HH:MM)"
@@ -46,9 +68,22 @@ func NewFlightHandler(fs *service.SearchFlightsUseCase) FlightHandler {
 //
 // @Param sort_by query string false "Sort option" Enums(price_asc,price_desc,duration_asc,duration_desc,departure_asc,arrival_asc,best_value)
 //
+// @Param return_date query string false "Return date (YYYY-MM-DD), enables round-trip search"
+// @Param return_max_stops query int false "Maximum stops on the return leg"
+// @Param return_earliest_departure query string false "Return leg earliest departure time (HH:MM)"
+// @Param return_latest_departure query string false "Return leg latest departure time (HH:MM)"
+// @Param return_earliest_arrival query string false "Return leg earliest arrival time (HH:MM)"
+// @Param return_latest_arrival query string false "Return leg latest arrival time (HH:MM)"
+//
+// @Param flex_days query int false "Add a cheapest-fare grid for ±N days around the dates (max 3)"
+//
+// @Param page_size query int false "Return flights in pages of this size (max 200, one-way only)"
+// @Param cursor query string false "page.next_cursor of the previous page; repeat the other parameters unchanged"
+//
 // @Success 200 {object} domain.FlightSearchResponse
 // @Failure 400 {string} string "Bad Request"
 // @Failure 405 {string} string "Method Not Allowed"
+// @Failure 410 {string} string "Cursor expired"
 // @Failure 500 {string} string "Internal Server Error"
 //
 // @Router /search [get]
@@ -60,13 +95,346 @@ func (h *FlightHandler) Search(w http.ResponseWriter, r *http.Request) {
 	}
 
 	start := time.Now()
+
+	req, err := parseSearchRequest(r, h.FlightService.FX)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var resp domain.FlightSearchResponse
+	if req.ReturnDate != "" {
+		result, err := h.FlightService.ExecuteRoundTrip(r.Context(), req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		resp = newRoundTripResponse(req, result, start)
+	} else if req.PageSize > 0 {
+		result, page, err := h.FlightService.ExecutePage(r.Context(), req)
+		switch {
+		case errors.Is(err, domain.ErrInvalidCursor):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		case errors.Is(err, domain.ErrCursorExpired):
+			http.Error(w, err.Error(), http.StatusGone)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		resp = newSearchResponse(req, result, start)
+		resp.Page = &page
+	} else {
+		result, err := h.FlightService.Execute(r.Context(), req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		resp = newSearchResponse(req, result, start)
+	}
+
+	if req.FlexDays > 0 {
+		grid, err := h.FlightService.ExecuteFlexGrid(r.Context(), req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		resp.DateGrid = grid.Outbound
+		resp.ReturnDateGrid = grid.Inbound
+		resp.DateMatrix = grid.Matrix
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// SearchFlightsStream godoc
+// @Summary      Stream flight search results
+// @Description  Same parameters as a one-way /search; return_date, flex_days, page_size and cursor are rejected. Emits a "provider" Server-Sent Event as soon as each provider answers, carrying that provider's filtered and sorted flights plus running metadata, then a "complete" event with the merged, globally sorted response.
+// @Tags         Flights
+// @Produce      text/event-stream
+//
+// @Param origin query string true "Origin airport or city code (e.g. CGK, JKT)"
+// @Param destination query string true "Destination airport or city code (e.g. DPS)"
+// @Param departure_date query string true "Departure date (YYYY-MM-DD)"
+// @Param passengers query int false "Number of passengers; flights with fewer seats left are dropped"
+// @Param cabin_class query string false "Cabin class" Enums(economy,premium_economy,business,first)
+// @Param nearby_km query int false "Also search airports within this radius (km) of origin and destination"
+// @Param sort_by query string false "Sort option" Enums(price_asc,price_desc,duration_asc,duration_desc,departure_asc,arrival_asc,best_value)
+//
+// @Success 200 {object} domain.SearchStreamEvent "provider event; the complete event carries domain.FlightSearchResponse"
+// @Failure 400 {string} string "Bad Request"
+// @Failure 405 {string} string "Method Not Allowed"
+// @Failure 500 {string} string "Internal Server Error"
+//
+// @Router /search/stream [get]
+func (h *FlightHandler) SearchStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	start := time.Now()
+
+	// the stream only runs a one-way, unpaged search
+	for _, name := range []string{"return_date", "flex_days", "page_size", "cursor"} {
+		if r.URL.Query().Has(name) {
+			http.Error(w, name+" is not supported on /search/stream", http.StatusBadRequest)
+			return
+		}
+	}
+
+	req, err := parseSearchRequest(r, h.FlightService.FX)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	result, err := h.FlightService.ExecuteStream(r.Context(), req, func(u domain.ProviderUpdate) {
+		event := domain.SearchStreamEvent{
+			Provider: u.Provider,
+			Metadata: newMetadata(u.Result, start),
+			Flights:  u.Result.Flights,
+		}
+		if u.Err != nil {
+			event.Error = u.Err.Error()
+		}
+		writeEvent(w, "provider", event)
+		flusher.Flush()
+	})
+	if err != nil {
+		writeEvent(w, "error", map[string]string{"error": err.Error()})
+		flusher.Flush()
+		return
+	}
+
+	writeEvent(w, "complete", newSearchResponse(req, result, start))
+	flusher.Flush()
+}
+
+// SearchMultiCityFlights godoc
+// @Summary      Search multi-city itineraries
+// @Description  Searches every leg in parallel and combines one flight per leg into ranked itineraries. Consecutive flights must leave at least min_gap minutes between arrival and the next departure. Filters apply per leg; min_price and max_price apply to the itinerary total.
+// @Tags         Flights
+// @Produce      json
+//
+// @Param legs query string true "Legs in travel order as ORIGIN:DESTINATION:YYYY-MM-DD (CSV or repeated), e.g. CGK:DPS:2025-12-15,DPS:SUB:2025-12-17"
+// @Param min_gap query int false "Minimum minutes between a leg's arrival and the next departure (default 120)"
+// @Param passengers query int false "Number of passengers; flights with fewer seats left are dropped"
+// @Param cabin_class query string false "Cabin class" Enums(economy,premium_economy,business,first)
+//
+// @Param currency query string false "Display currency (e.g. SGD, MYR, USD); min_price and max_price are given in it"
+// @Param min_price query number false "Minimum itinerary total (IDR, or currency when set)"
+// @Param max_price query number false "Maximum itinerary total (IDR, or currency when set)"
+// @Param price_basis query string false "Price the price filters use: per passenger (default) or the party total" Enums(per_person,total)
+// @Param max_stops query int false "Maximum stops per leg"
+// @Param max_duration query int false "Maximum duration per leg (minutes)"
+// @Param max_layover query int false "Maximum minutes of any single layover"
+// @Param min_connection query int false "Minimum minutes of any single layover"
+// @Param exclude_connections query string false "Airport or city codes not to connect in (CSV or repeated), e.g. SUB,UPG"
+// @Param checked_bag_included query bool false "Only flights whose fare includes a checked bag"
+// @Param airlines query string false "Airline codes (CSV or repeated), e.g. GA,ID"
+//
+// @Param sort_by query string false "Sort option" Enums(price_asc,price_desc,duration_asc,best_value)
+//
+// @Success 200 {object} domain.MultiCitySearchResponse
+// @Failure 400 {string} string "Bad Request"
+// @Failure 405 {string} string "Method Not Allowed"
+// @Failure 500 {string} string "Internal Server Error"
+//
+// @Router /search/multi-city [get]
+func (h *FlightHandler) SearchMultiCity(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	start := time.Now()
+
+	req, err := parseMultiCityRequest(r, h.FlightService.FX)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.FlightService.ExecuteMultiCity(r.Context(), req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := domain.MultiCitySearchResponse{
+		Legs:        make([]domain.LegSummary, len(req.Legs)),
+		Itineraries: result.Itineraries,
+	}
+	for i, leg := range req.Legs {
+		resp.Legs[i] = domain.LegSummary{
+			SearchCriteria: domain.SearchCriteria{
+				Origin:        leg.Origin,
+				Destination:   leg.Destination,
+				DepartureDate: leg.DepartureDate,
+				Passengers:    req.Search.Passengers,
+				CabinClass:    req.Search.CabinClass,
+			},
+			Metadata: newMetadata(result.Legs[i], start),
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// FlightCalendar godoc
+// @Summary      Lowest fare per day for a month
+// @Description  Searches every day of the month on a route (bounded concurrency, cached days reused) and returns the lowest fare, its carrier and direct-flight availability per day. Days where a provider failed are marked incomplete.
+// @Tags         Flights
+// @Produce      json
+//
+// @Param origin query string true "Origin airport or city code (e.g. CGK, JKT)"
+// @Param destination query string true "Destination airport or city code (e.g. DPS)"
+// @Param month query string true "Month (YYYY-MM)"
+// @Param passengers query int false "Number of passengers; flights with fewer seats left are dropped"
+// @Param cabin_class query string false "Cabin class" Enums(economy,premium_economy,business,first)
+// @Param max_stops query int false "Maximum stops"
+// @Param airlines query string false "Airline codes (CSV or repeated), e.g. GA,ID"
+//
+// @Success 200 {object} domain.CalendarResponse
+// @Failure 400 {string} string "Bad Request"
+// @Failure 405 {string} string "Method Not Allowed"
+// @Failure 500 {string} string "Internal Server Error"
+//
+// @Router /calendar [get]
+func (h *FlightHandler) Calendar(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	start := time.Now()
+
+	q := r.URL.Query()
+	req, err := parseSearchFilters(q, h.FlightService.FX)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.Origin = q.Get("origin")
+	req.Destination = q.Get("destination")
+	month := q.Get("month")
+
+	if req.Origin == "" || req.Destination == "" || month == "" {
+		http.Error(w, "missing required query parameters", http.StatusBadRequest)
+		return
+	}
+	if _, err := time.Parse("2006-01", month); err != nil {
+		http.Error(w, "month must be YYYY-MM", http.StatusBadRequest)
+		return
+	}
+
+	days, err := h.FlightService.ExecuteCalendar(r.Context(), req, month)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := domain.CalendarResponse{
+		Origin:       req.Origin,
+		Destination:  req.Destination,
+		Month:        month,
+		SearchTimeMS: int(time.Since(start).Milliseconds()),
+		Days:         days,
+	}
+	for _, d := range days {
+		if d.Incomplete {
+			resp.IncompleteDays++
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func parseSearchRequest(r *http.Request, rates common.RateLookup) (domain.SearchRequest, error) {
 	q := r.URL.Query()
 
+	req, err := parseSearchFilters(q, rates)
+	if err != nil {
+		return req, err
+	}
+	req.Origin = q.Get("origin")
+	req.Destination = q.Get("destination")
+	req.DepartureDate = q.Get("departure_date")
+	req.ReturnDate = q.Get("return_date")
+
+	if req.Origin == "" || req.Destination == "" || req.DepartureDate == "" {
+		return req, errors.New("missing required query parameters")
+	}
+
+	if v := q.Get("flex_days"); v != "" {
+		d, err := strconv.Atoi(v)
+		if err != nil || d < 0 || d > maxFlexDays {
+			return req, fmt.Errorf("flex_days must be between 0 and %d", maxFlexDays)
+		}
+		req.FlexDays = d
+	}
+
+	departure, err := time.Parse("2006-01-02", req.DepartureDate)
+	if err != nil {
+		return req, errors.New("departure_date must be YYYY-MM-DD")
+	}
+	if req.ReturnDate != "" {
+		ret, err := time.Parse("2006-01-02", req.ReturnDate)
+		if err != nil {
+			return req, errors.New("return_date must be YYYY-MM-DD")
+		}
+		if ret.Before(departure) {
+			return req, errors.New("return_date must not be before departure_date")
+		}
+	}
+
+	req.Cursor = q.Get("cursor")
+	if v := q.Get("page_size"); v != "" {
+		size, err := strconv.Atoi(v)
+		if err != nil || size < 1 || size > maxPageSize {
+			return req, fmt.Errorf("page_size must be between 1 and %d", maxPageSize)
+		}
+		req.PageSize = size
+	}
+	if req.Cursor != "" && req.PageSize == 0 {
+		return req, errors.New("cursor requires page_size")
+	}
+	if req.PageSize > 0 && req.ReturnDate != "" {
+		return req, errors.New("page_size is only supported for one-way searches")
+	}
+
+	return req, nil
+
+}
+
+// parseSearchFilters reads the passenger, cabin, filter and sort parameters
+// shared by every search endpoint.
+func parseSearchFilters(q url.Values, rates common.RateLookup) (domain.SearchRequest, error) {
 	req := domain.SearchRequest{
-		Origin:        q.Get("origin"),
-		Destination:   q.Get("destination"),
-		DepartureDate: q.Get("departure_date"),
-		CabinClass:    q.Get("cabin_class"),
+		CabinClass: q.Get("cabin_class"),
 
 		// optional filters (strings first)
 		EarliestDep: q.Get("earliest_departure"),
@@ -74,6 +442,11 @@ func (h *FlightHandler) Search(w http.ResponseWriter, r *http.Request) {
 		EarliestArr: q.Get("earliest_arrival"),
 		LatestArr:   q.Get("latest_arrival"),
 		SortBy:      q.Get("sort_by"),
+
+		ReturnEarliestDep: q.Get("return_earliest_departure"),
+		ReturnLatestDep:   q.Get("return_latest_departure"),
+		ReturnEarliestArr: q.Get("return_earliest_arrival"),
+		ReturnLatestArr:   q.Get("return_latest_arrival"),
 	}
 
 	// passengers
@@ -83,19 +456,59 @@ func (h *FlightHandler) Search(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if v := q.Get("facets"); v != "" {
+		if include, err := strconv.ParseBool(v); err == nil {
+			req.SkipFacets = !include
+		}
+	}
+
+	if v := q.Get("checked_bag_included"); v != "" {
+		req.CheckedBagIncluded, _ = strconv.ParseBool(v)
+	}
+
+	if v := q.Get("self_transfer"); v != "" {
+		req.SelfTransfer, _ = strconv.ParseBool(v)
+	}
+
+	if v := q.Get("nearby_km"); v != "" {
+		if km, err := strconv.Atoi(v); err == nil && km > 0 {
+			req.NearbyKM = km
+		}
+	}
+
+	// display currency; prices are given in it and filtered in IDR
+	priceCurrency := "IDR"
+	if v := strings.ToUpper(strings.TrimSpace(q.Get("currency"))); v != "" {
+		if v != "IDR" {
+			if _, err := common.ConvertFromIDR(0, v, rates); err != nil {
+				return req, fmt.Errorf("unsupported currency %q", v)
+			}
+		}
+		req.Currency = v
+		priceCurrency = v
+	}
+
 	// prices
 	if v := q.Get("min_price"); v != "" {
-		if p, err := strconv.ParseInt(v, 10, 64); err == nil {
-			req.MinPrice = p
+		if p, err := common.ParsePriceToIDR(v, priceCurrency, rates); err == nil {
+			req.MinPrice = p.IDR
 		}
 	}
 
 	if v := q.Get("max_price"); v != "" {
-		if p, err := strconv.ParseInt(v, 10, 64); err == nil {
-			req.MaxPrice = p
+		if p, err := common.ParsePriceToIDR(v, priceCurrency, rates); err == nil {
+			req.MaxPrice = p.IDR
 		}
 	}
 
+	switch basis := domain.PriceBasis(q.Get("price_basis")); basis {
+	case "":
+	case domain.PricePerPerson, domain.PriceTotal:
+		req.PriceBasis = basis
+	default:
+		return req, errors.New("price_basis must be per_person or total")
+	}
+
 	// stops
 	if v := q.Get("max_stops"); v != "" {
 		if s, err := strconv.Atoi(v); err == nil {
@@ -105,6 +518,14 @@ func (h *FlightHandler) Search(w http.ResponseWriter, r *http.Request) {
 		req.MaxStops = -1 // default unset
 	}
 
+	if v := q.Get("return_max_stops"); v != "" {
+		if s, err := strconv.Atoi(v); err == nil {
+			req.ReturnMaxStops = s
+		}
+	} else {
+		req.ReturnMaxStops = -1 // default unset
+	}
+
 	// duration
 	if v := q.Get("max_duration"); v != "" {
 		if d, err := strconv.Atoi(v); err == nil {
@@ -112,6 +533,23 @@ func (h *FlightHandler) Search(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// layovers
+	if v := q.Get("max_layover"); v != "" {
+		if m, err := strconv.Atoi(v); err == nil {
+			req.MaxLayover = m
+		}
+	}
+
+	if v := q.Get("min_connection"); v != "" {
+		if m, err := strconv.Atoi(v); err == nil {
+			req.MinConnection = m
+		}
+	}
+
+	for _, v := range q["exclude_connections"] {
+		req.ExcludeConnections = append(req.ExcludeConnections, strings.Split(v, ",")...)
+	}
+
 	if airlines := q["airlines"]; len(airlines) > 0 {
 		var parsed []string
 		for _, a := range airlines {
@@ -120,37 +558,132 @@ func (h *FlightHandler) Search(w http.ResponseWriter, r *http.Request) {
 		req.Airlines = parsed
 	}
 
-	if req.Origin == "" || req.Destination == "" || req.DepartureDate == "" {
-		http.Error(w, "missing required query parameters", http.StatusBadRequest)
-		return
+	if req.CabinClass != "" && common.NormalizeCabin(req.CabinClass) == "" {
+		return req, errors.New("cabin_class must be economy, premium_economy, business or first")
 	}
 
-	result, err := h.FlightService.Execute(r.Context(), req)
+	return req, nil
+}
+
+// parseMultiCityRequest reads the legs (ORIGIN:DESTINATION:YYYY-MM-DD, CSV
+// or repeated) and the optional min_gap in minutes on top of the shared
+// search parameters.
+func parseMultiCityRequest(r *http.Request, rates common.RateLookup) (domain.MultiCityRequest, error) {
+	q := r.URL.Query()
+
+	search, err := parseSearchFilters(q, rates)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+		return domain.MultiCityRequest{}, err
+	}
+
+	req := domain.MultiCityRequest{
+		Search: search,
+		MinGap: -1, // default unset
+	}
+
+	for _, v := range q["legs"] {
+		for _, raw := range strings.Split(v, ",") {
+			parts := strings.Split(strings.TrimSpace(raw), ":")
+			if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+				return req, fmt.Errorf("invalid leg %q, expected ORIGIN:DESTINATION:YYYY-MM-DD", raw)
+			}
+			req.Legs = append(req.Legs, domain.Leg{
+				Origin:        parts[0],
+				Destination:   parts[1],
+				DepartureDate: parts[2],
+			})
+		}
+	}
+
+	if len(req.Legs) < 2 || len(req.Legs) > maxMultiCityLegs {
+		return req, fmt.Errorf("between 2 and %d legs are required", maxMultiCityLegs)
+	}
+
+	var prev time.Time
+	for i, leg := range req.Legs {
+		date, err := time.Parse("2006-01-02", leg.DepartureDate)
+		if err != nil {
+			return req, fmt.Errorf("leg %d date must be YYYY-MM-DD", i+1)
+		}
+		if date.Before(prev) {
+			return req, errors.New("legs must be in departure date order")
+		}
+		prev = date
 	}
 
-	resp := domain.FlightSearchResponse{
+	if v := q.Get("min_gap"); v != "" {
+		if g, err := strconv.Atoi(v); err == nil && g >= 0 {
+			req.MinGap = g
+		}
+	}
+
+	return req, nil
+}
+
+func newSearchResponse(
+	req domain.SearchRequest,
+	result domain.SearchResult,
+	start time.Time,
+) domain.FlightSearchResponse {
+	return domain.FlightSearchResponse{
 		SearchCriteria: domain.SearchCriteria{
 			Origin:        req.Origin,
 			Destination:   req.Destination,
 			DepartureDate: req.DepartureDate,
 			Passengers:    req.Passengers,
 			CabinClass:    req.CabinClass,
+			Currency:      req.Currency,
+			PriceBasis:    req.PriceBasis,
+
+			OriginAirports:      result.OriginAirports,
+			DestinationAirports: result.DestinationAirports,
 		},
-		Metadata: domain.Metadata{
-			TotalResults:       len(result.Flights),
-			ProvidersQueried:   result.ProvidersQueried,
-			ProvidersSucceeded: result.ProvidersSucceeded,
-			ProvidersFailed:    result.ProvidersFailed,
-			SearchTimeMS:       int(time.Since(start).Milliseconds()),
-			CacheHit:           result.CacheHit,
-		},
-		Flights: result.Flights,
+		Metadata: newMetadata(result, start),
+		Flights:  result.Flights,
+		Facets:   result.Facets,
 	}
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	_ = json.NewEncoder(w).Encode(resp)
+func newRoundTripResponse(
+	req domain.SearchRequest,
+	result domain.RoundTripResult,
+	start time.Time,
+) domain.FlightSearchResponse {
+	resp := newSearchResponse(req, result.Outbound, start)
+	resp.SearchCriteria.ReturnDate = req.ReturnDate
+
+	returnMetadata := newMetadata(result.Inbound, start)
+	resp.ReturnMetadata = &returnMetadata
+	resp.ReturnFlights = result.Inbound.Flights
+	resp.RoundTrips = result.RoundTrips
+
+	return resp
+}
+
+func newMetadata(result domain.SearchResult, start time.Time) domain.Metadata {
+	return domain.Metadata{
+		TotalResults:       len(result.Flights),
+		ProvidersQueried:   result.ProvidersQueried,
+		ProvidersSucceeded: result.ProvidersSucceeded,
+		ProvidersFailed:    result.ProvidersFailed,
+		ProvidersTimedOut:  result.ProvidersTimedOut,
+		SkippedCircuitOpen: result.SkippedCircuitOpen,
+		HedgedRequests:     result.HedgedRequests,
+		HedgesWon:          result.HedgesWon,
+		Providers:          result.Providers,
+		SearchTimeMS:       int(time.Since(start).Milliseconds()),
+		CacheHit:           result.CacheHit,
+		Stale:              result.Stale,
+		Coalesced:          result.Coalesced,
+		SelfTransfers:      result.SelfTransfers,
+	}
+}
+
+// writeEvent writes a single Server-Sent Event with a JSON payload.
+func writeEvent(w http.ResponseWriter, event string, payload interface{}) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
 }
@@ -6,8 +6,9 @@ import (
 )
 
 type cacheItem struct {
-	value     interface{}
-	expiredAt time.Time
+	value      interface{}
+	expiredAt  time.Time
+	staleUntil time.Time
 }
 
 type Cache struct {
@@ -24,14 +25,89 @@ func (c *Cache) Get(k string) (interface{}, bool) {
 	item, ok := c.m[k]
 	c.mu.RUnlock()
 
-	if !ok || time.Now().After(item.expiredAt) {
+	now := time.Now()
+	if ok && now.After(item.staleUntil) {
+		c.evict(k, item)
+	}
+	if !ok || now.After(item.expiredAt) {
 		return nil, false
 	}
 	return item.value, true
 }
 
+// GetWithStale is like Get but also returns entries past their TTL that are
+// still inside their stale window, with stale set to true.
+func (c *Cache) GetWithStale(k string) (v interface{}, stale bool, ok bool) {
+	c.mu.RLock()
+	item, ok := c.m[k]
+	c.mu.RUnlock()
+
+	now := time.Now()
+	if !ok {
+		return nil, false, false
+	}
+	if now.After(item.staleUntil) {
+		c.evict(k, item)
+		return nil, false, false
+	}
+	return item.value, now.After(item.expiredAt), true
+}
+
 func (c *Cache) Set(k string, v interface{}, ttl time.Duration) {
+	c.SetWithStale(k, v, ttl, ttl)
+}
+
+// SetWithStale stores v as fresh for ttl and servable as stale until
+// staleTTL has passed. A staleTTL shorter than ttl is treated as ttl.
+func (c *Cache) SetWithStale(k string, v interface{}, ttl, staleTTL time.Duration) {
+	if staleTTL < ttl {
+		staleTTL = ttl
+	}
+
+	now := time.Now()
 	c.mu.Lock()
-	c.m[k] = cacheItem{value: v, expiredAt: time.Now().Add(ttl)}
+	c.m[k] = cacheItem{value: v, expiredAt: now.Add(ttl), staleUntil: now.Add(staleTTL)}
 	c.mu.Unlock()
 }
+
+// evict deletes k unless it was set again since the reader saw item.
+func (c *Cache) evict(k string, item cacheItem) {
+	c.mu.Lock()
+	if cur, ok := c.m[k]; ok && cur.staleUntil.Equal(item.staleUntil) {
+		delete(c.m, k)
+	}
+	c.mu.Unlock()
+}
+
+// Sweep deletes every entry past its stale window, including keys that are
+// never read again.
+func (c *Cache) Sweep() {
+	now := time.Now()
+	c.mu.Lock()
+	for k, item := range c.m {
+		if now.After(item.staleUntil) {
+			delete(c.m, k)
+		}
+	}
+	c.mu.Unlock()
+}
+
+// StartSweeper runs Sweep every interval until stop is called.
+func (c *Cache) StartSweeper(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				c.Sweep()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
@@ -0,0 +1,92 @@
+package infra
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCacheGetWithStale(t *testing.T) {
+	c := NewCache()
+	c.SetWithStale("k", "v", 20*time.Millisecond, 60*time.Millisecond)
+
+	if v, stale, ok := c.GetWithStale("k"); !ok || stale || v != "v" {
+		t.Fatalf("fresh entry = %v, %t, %t", v, stale, ok)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if _, ok := c.Get("k"); ok {
+		t.Fatal("Get served an expired entry")
+	}
+	if v, stale, ok := c.GetWithStale("k"); !ok || !stale || v != "v" {
+		t.Fatalf("stale entry = %v, %t, %t", v, stale, ok)
+	}
+
+	time.Sleep(40 * time.Millisecond)
+	if _, _, ok := c.GetWithStale("k"); ok {
+		t.Fatal("entry served past its stale window")
+	}
+}
+
+func TestCacheEvictsDeadEntries(t *testing.T) {
+	tests := []struct {
+		name string
+		read func(c *Cache)
+	}{
+		{name: "on Get", read: func(c *Cache) { c.Get("k") }},
+		{name: "on GetWithStale", read: func(c *Cache) { c.GetWithStale("k") }},
+		{name: "on Sweep", read: func(c *Cache) { c.Sweep() }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCache()
+			c.SetWithStale("k", "v", time.Millisecond, 5*time.Millisecond)
+			c.Set("live", "v", time.Minute)
+
+			time.Sleep(10 * time.Millisecond)
+			tt.read(c)
+
+			if _, ok := c.m["k"]; ok {
+				t.Fatal("dead entry still stored")
+			}
+			if _, ok := c.m["live"]; !ok {
+				t.Fatal("live entry removed")
+			}
+		})
+	}
+}
+
+func TestCacheGetKeepsStaleEntries(t *testing.T) {
+	c := NewCache()
+	c.SetWithStale("k", "v", time.Millisecond, time.Minute)
+
+	time.Sleep(5 * time.Millisecond)
+	c.Get("k")
+
+	if _, _, ok := c.GetWithStale("k"); !ok {
+		t.Fatal("Get removed an entry still inside its stale window")
+	}
+}
+
+func TestCacheSweeper(t *testing.T) {
+	c := NewCache()
+	stop := c.StartSweeper(5 * time.Millisecond)
+	defer stop()
+
+	c.Set("k", "v", time.Millisecond)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		c.mu.RLock()
+		n := len(c.m)
+		c.mu.RUnlock()
+		if n == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("sweeper never removed the dead entry")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	stop()
+}
@@ -0,0 +1,167 @@
+package infra
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"bookcabin/internal/domain"
+)
+
+// RateSnapshot is a full set of exchange rates: how many IDR one unit of each
+// currency is worth, as decimal strings.
+type RateSnapshot struct {
+	Base  string            `json:"base"`
+	AsOf  string            `json:"as_of"`
+	Rates map[string]string `json:"rates"`
+}
+
+// RateSource loads rate snapshots.
+type RateSource interface {
+	LoadRates(ctx context.Context) (RateSnapshot, error)
+}
+
+// FileRateSource reads a JSON rate snapshot from a file.
+type FileRateSource struct {
+	Path string
+}
+
+func (s FileRateSource) LoadRates(ctx context.Context) (RateSnapshot, error) {
+	b, err := os.ReadFile(s.Path)
+	if err != nil {
+		return RateSnapshot{}, err
+	}
+
+	var snap RateSnapshot
+	if err := json.Unmarshal(b, &snap); err != nil {
+		return RateSnapshot{}, err
+	}
+	return snap, nil
+}
+
+// HTTPRateSource fetches a JSON rate snapshot from a URL.
+type HTTPRateSource struct {
+	URL    string
+	Client *http.Client
+}
+
+func (s HTTPRateSource) LoadRates(ctx context.Context) (RateSnapshot, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		return RateSnapshot{}, err
+	}
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return RateSnapshot{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return RateSnapshot{}, fmt.Errorf("rate source returned status %d", resp.StatusCode)
+	}
+
+	var snap RateSnapshot
+	if err := json.NewDecoder(resp.Body).Decode(&snap); err != nil {
+		return RateSnapshot{}, err
+	}
+	return snap, nil
+}
+
+// FXRates serves IDR exchange rates from a cached snapshot, reloading it from
+// Source once it is older than TTL (default 1h). When a reload fails the
+// previous snapshot keeps being served and the reload is retried a minute
+// later.
+type FXRates struct {
+	Source RateSource
+	TTL    time.Duration
+
+	mu       sync.RWMutex
+	snapshot RateSnapshot
+	nextLoad time.Time
+
+	// loads shares one reload between every caller that finds it due
+	loads Group
+}
+
+// Rate returns how many IDR one unit of currency is worth.
+func (f *FXRates) Rate(currency string) (string, error) {
+	if f.due() {
+		f.loads.Do(context.Background(), "rates", func(ctx context.Context) (interface{}, error) {
+			// a reload that just finished may have made this one unnecessary
+			if f.due() {
+				ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+				defer cancel()
+				f.load(ctx)
+			}
+			return nil, nil
+		})
+	}
+
+	f.mu.RLock()
+	rates := f.snapshot.Rates
+	f.mu.RUnlock()
+
+	if rates == nil {
+		return "", errors.New("no exchange rates loaded")
+	}
+
+	rate, ok := rates[strings.ToUpper(currency)]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", domain.ErrUnknownCurrency, currency)
+	}
+	return rate, nil
+}
+
+func (f *FXRates) due() bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return time.Now().After(f.nextLoad)
+}
+
+// load replaces the snapshot if the source returns a valid one. mu is only
+// held to swap the snapshot, never while the source is called.
+func (f *FXRates) load(ctx context.Context) {
+	snap, err := f.Source.LoadRates(ctx)
+	if err == nil {
+		err = validateSnapshot(snap)
+	}
+	if err != nil {
+		f.mu.Lock()
+		f.nextLoad = time.Now().Add(time.Minute)
+		f.mu.Unlock()
+		log.Printf("[WARN] exchange rates not refreshed: %v", err)
+		return
+	}
+
+	ttl := f.TTL
+	if ttl <= 0 {
+		ttl = time.Hour
+	}
+
+	f.mu.Lock()
+	f.snapshot = snap
+	f.nextLoad = time.Now().Add(ttl)
+	f.mu.Unlock()
+	log.Printf("[INFO] exchange rates loaded, as of %s (%d currencies)", snap.AsOf, len(snap.Rates))
+}
+
+func validateSnapshot(snap RateSnapshot) error {
+	if !strings.EqualFold(snap.Base, "IDR") {
+		return fmt.Errorf("rate snapshot base is %q, want IDR", snap.Base)
+	}
+	for currency, rate := range snap.Rates {
+		if r, ok := new(big.Rat).SetString(rate); !ok || r.Sign() <= 0 {
+			return fmt.Errorf("invalid %s rate %q", currency, rate)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package infra
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingRateSource counts its loads, each taking delay, and fails with err
+// when set.
+type countingRateSource struct {
+	loads atomic.Int32
+	delay time.Duration
+	err   error
+}
+
+func (s *countingRateSource) LoadRates(ctx context.Context) (RateSnapshot, error) {
+	s.loads.Add(1)
+	time.Sleep(s.delay)
+	if s.err != nil {
+		return RateSnapshot{}, s.err
+	}
+	return RateSnapshot{Base: "IDR", AsOf: "2025-12-15", Rates: map[string]string{"SGD": "12000"}}, nil
+}
+
+func TestFXRatesSharesOneReload(t *testing.T) {
+	src := &countingRateSource{delay: 20 * time.Millisecond}
+	fx := &FXRates{Source: src, TTL: time.Hour}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if rate, err := fx.Rate("sgd"); err != nil || rate != "12000" {
+				t.Errorf("Rate = %q, %v", rate, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := src.loads.Load(); n != 1 {
+		t.Fatalf("source loaded %d times, want 1", n)
+	}
+}
+
+func TestFXRatesKeepsSnapshotWhenReloadFails(t *testing.T) {
+	src := &countingRateSource{}
+	fx := &FXRates{Source: src, TTL: time.Millisecond}
+
+	if _, err := fx.Rate("SGD"); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	src.err = errors.New("source down")
+	if rate, err := fx.Rate("SGD"); err != nil || rate != "12000" {
+		t.Fatalf("Rate after a failed reload = %q, %v, want the previous snapshot", rate, err)
+	}
+
+	// the failed reload is retried a minute later, not on every call
+	fx.Rate("SGD")
+	if n := src.loads.Load(); n != 2 {
+		t.Fatalf("source loaded %d times, want 2", n)
+	}
+}
@@ -0,0 +1,85 @@
+package infra
+
+import (
+	"context"
+	"sync"
+)
+
+// Group coalesces concurrent calls with the same key into a single execution
+// whose result is handed to every caller. The shared call runs on its own
+// context, detached from any single caller, and is cancelled only once every
+// caller waiting for it has gone away. The zero value is ready to use.
+type Group struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+type call struct {
+	done    chan struct{}
+	val     interface{}
+	err     error
+	waiters int
+	dups    int
+	cancel  context.CancelFunc
+}
+
+// Do runs fn once for all concurrent callers of key and returns its result.
+// A caller whose ctx ends stops waiting and gets ctx.Err() without affecting
+// the others. shared reports whether the result was handed to more than one
+// caller.
+func (g *Group) Do(
+	ctx context.Context,
+	key string,
+	fn func(ctx context.Context) (interface{}, error),
+) (v interface{}, err error, shared bool) {
+
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*call)
+	}
+
+	c, ok := g.calls[key]
+	if ok {
+		c.dups++
+	} else {
+		callCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		c = &call{done: make(chan struct{}), cancel: cancel}
+		g.calls[key] = c
+
+		go func() {
+			c.val, c.err = fn(callCtx)
+			cancel()
+
+			g.mu.Lock()
+			if g.calls[key] == c {
+				delete(g.calls, key)
+			}
+			g.mu.Unlock()
+
+			close(c.done)
+		}()
+	}
+	c.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+		g.mu.Lock()
+		shared = c.dups > 0
+		g.mu.Unlock()
+		return c.val, c.err, shared
+
+	case <-ctx.Done():
+		g.mu.Lock()
+		c.waiters--
+		if c.waiters == 0 {
+			// nobody needs the result anymore
+			c.cancel()
+			if g.calls[key] == c {
+				delete(g.calls, key)
+			}
+		}
+		g.mu.Unlock()
+		return nil, ctx.Err(), false
+	}
+}
@@ -0,0 +1,162 @@
+package infra
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitForWaiters blocks until key has n callers waiting on it.
+func waitForWaiters(t *testing.T, g *Group, key string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		g.mu.Lock()
+		c, ok := g.calls[key]
+		got := 0
+		if ok {
+			got = c.waiters
+		}
+		g.mu.Unlock()
+		if got == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("key %q never reached %d waiters", key, n)
+}
+
+func TestGroupDoCoalescesConcurrentCalls(t *testing.T) {
+	var g Group
+	var runs atomic.Int32
+	release := make(chan struct{})
+
+	fn := func(ctx context.Context) (interface{}, error) {
+		runs.Add(1)
+		<-release
+		return "result", nil
+	}
+
+	const callers = 5
+	var wg sync.WaitGroup
+	results := make([]interface{}, callers)
+	shared := make([]bool, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _, shared[i] = g.Do(context.Background(), "k", fn)
+		}(i)
+	}
+
+	waitForWaiters(t, &g, "k", callers)
+	close(release)
+	wg.Wait()
+
+	if n := runs.Load(); n != 1 {
+		t.Fatalf("fn ran %d times, want 1", n)
+	}
+	for i := range results {
+		if results[i] != "result" || !shared[i] {
+			t.Fatalf("caller %d got %v (shared %t), want the shared result", i, results[i], shared[i])
+		}
+	}
+
+	// the key is released once the call is done
+	v, _, sh := g.Do(context.Background(), "k", func(ctx context.Context) (interface{}, error) {
+		return "again", nil
+	})
+	if v != "again" || sh {
+		t.Fatalf("second round got %v (shared %t), want a fresh unshared call", v, sh)
+	}
+}
+
+func TestGroupDoOneWaiterLeavingKeepsCallRunning(t *testing.T) {
+	var g Group
+	release := make(chan struct{})
+	fnCtx := make(chan context.Context, 1)
+
+	fn := func(ctx context.Context) (interface{}, error) {
+		fnCtx <- ctx
+		<-release
+		return "result", nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	leaver := make(chan error, 1)
+	go func() {
+		_, err, _ := g.Do(ctx, "k", fn)
+		leaver <- err
+	}()
+
+	stayer := make(chan interface{}, 1)
+	go func() {
+		v, _, _ := g.Do(context.Background(), "k", fn)
+		stayer <- v
+	}()
+
+	waitForWaiters(t, &g, "k", 2)
+	cancel()
+	if err := <-leaver; !errors.Is(err, context.Canceled) {
+		t.Fatalf("leaving caller got %v, want %v", err, context.Canceled)
+	}
+
+	if err := (<-fnCtx).Err(); err != nil {
+		t.Fatalf("shared call cancelled while a caller still waits: %v", err)
+	}
+	close(release)
+	if v := <-stayer; v != "result" {
+		t.Fatalf("remaining caller got %v, want the result", v)
+	}
+}
+
+func TestGroupDoLastWaiterLeavingCancelsCall(t *testing.T) {
+	var g Group
+	fnCtx := make(chan context.Context, 1)
+	fnDone := make(chan struct{})
+
+	fn := func(ctx context.Context) (interface{}, error) {
+		defer close(fnDone)
+		fnCtx <- ctx
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	errs := make(chan error, 2)
+	for _, ctx := range []context.Context{ctx1, ctx2} {
+		go func(ctx context.Context) {
+			_, err, _ := g.Do(ctx, "k", fn)
+			errs <- err
+		}(ctx)
+	}
+
+	waitForWaiters(t, &g, "k", 2)
+	callCtx := <-fnCtx
+
+	cancel1()
+	<-errs
+	if callCtx.Err() != nil {
+		t.Fatal("shared call cancelled after the first of two callers left")
+	}
+
+	cancel2()
+	<-errs
+	select {
+	case <-fnDone:
+	case <-time.After(time.Second):
+		t.Fatal("shared call not cancelled after the last caller left")
+	}
+
+	// the abandoned call no longer holds the key
+	g.mu.Lock()
+	_, held := g.calls["k"]
+	g.mu.Unlock()
+	if held {
+		t.Fatal("abandoned call still registered under its key")
+	}
+}
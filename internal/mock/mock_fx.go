@@ -0,0 +1,33 @@
+package mock
+
+import (
+	"log"
+	"net/http"
+	"path/filepath"
+	"runtime"
+)
+
+// MockFXServer serves the static rate snapshot in fx_rates.json, standing in
+// for an exchange rate service.
+func MockFXServer() *http.Server {
+	_, filename, _, _ := runtime.Caller(0)
+	baseDir := filepath.Dir(filename)
+	jsonPath := filepath.Join(baseDir, "fx_rates.json")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/fx/rates", ServeJSONFile(jsonPath))
+
+	server := &http.Server{
+		Addr:    ":8085", // fixed port for curl
+		Handler: mux,
+	}
+
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Server failed: %v", err)
+		}
+	}()
+
+	log.Println("Mock fx server running at http://127.0.0.1:8085")
+	return server
+}
@@ -3,9 +3,13 @@ package provider
 import (
 	"bookcabin/internal/common"
 	"bookcabin/internal/domain"
+	"context"
 	"encoding/json"
-	"errors"
+	"fmt"
 	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
 )
 
 type AirAsiaResponse struct {
@@ -37,37 +41,71 @@ type AirAsiaStop struct {
 type AirAsiaProvider struct {
 	BaseURL string
 	Client  *http.Client
+	Retry   RetryPolicy
+	FX      common.RateLookup
 }
 
 func (a *AirAsiaProvider) Name() string { return "AirAsia" }
 
-func (a *AirAsiaProvider) Search(req domain.SearchRequest) ([]domain.Flight, error) {
-	resp, err := a.Client.Get(a.BaseURL + "/airasia/search")
+func (a *AirAsiaProvider) Search(ctx context.Context, req domain.SearchRequest) (domain.ProviderResult, error) {
+	resp, attempts, err := getWithRetry(ctx, a.Client, a.Retry, a.Name(), a.BaseURL+"/airasia/search")
+	result := domain.ProviderResult{Attempts: attempts}
 	if err != nil {
-		return nil, err
+		return result, err
 	}
 	defer resp.Body.Close()
 
 	var airAsiaRaw AirAsiaResponse
 	if err := json.NewDecoder(resp.Body).Decode(&airAsiaRaw); err != nil {
-		return nil, err
+		return result, fmt.Errorf("%w: %v", domain.ErrProviderDecode, err)
 	}
 
 	if airAsiaRaw.Status != StatusOK {
-		return nil, errors.New("air asia api returned failure")
+		return result, fmt.Errorf("air asia api returned failure: %w", domain.ErrProviderBusiness)
 	}
 
+	result.RawResults = len(airAsiaRaw.Flights)
+
 	flights := []domain.Flight{}
 	for _, r := range airAsiaRaw.Flights {
-		dep, _ := common.ParseFlexibleTime(r.DepartTime)
-		arr, _ := common.ParseFlexibleTime(r.ArriveTime)
+		dep, err := common.ParseFlexibleTime(r.DepartTime)
+		if err != nil {
+			result.DroppedNormalization++
+			continue
+		}
+
+		arr, err := common.ParseFlexibleTime(r.ArriveTime)
+		if err != nil {
+			result.DroppedNormalization++
+			continue
+		}
 
-		stops := 0
-		if !r.DirectFlight {
+		if len(r.FlightCode) < 2 {
+			result.DroppedNormalization++
+			continue
+		}
+
+		// AirAsia always quotes in IDR
+		price, err := common.ParsePriceToIDR(r.PriceIDR, "IDR", a.FX)
+		if err != nil {
+			result.DroppedNormalization++
+			continue
+		}
+
+		// the stop list is authoritative; a connecting flight without one
+		// counts as a single stop
+		stops := len(r.Stops)
+		if stops == 0 && !r.DirectFlight {
 			stops = 1
 		}
 
-		flights = append(flights, domain.Flight{
+		baggage, ok := airAsiaBaggage(r.BaggageNote)
+		if !ok {
+			result.UnparsedBaggage++
+			logUnparsedBaggage(a.Name(), r.FlightCode, r.BaggageNote)
+		}
+
+		f := domain.Flight{
 			FlightCode:     r.FlightCode,
 			Airline:        r.Airline,
 			AirlineCode:    r.FlightCode[:2],
@@ -77,11 +115,64 @@ func (a *AirAsiaProvider) Search(req domain.SearchRequest) ([]domain.Flight, err
 			ArrivalTime:    arr,
 			DurationMin:    int(r.DurationHrs * 60),
 			Stops:          stops,
-			PriceIDR:       int64(r.PriceIDR),
+			PriceIDR:       price.IDR,
+			Fare:           common.EstimateFare(price.IDR, req.Passengers),
 			AvailableSeats: r.Seats,
-			Baggage:        r.BaggageNote,
-		})
+			Cabin:          common.NormalizeCabin(r.CabinClass),
+			Baggage:        baggage,
+
+			OriginalAmount:   price.Amount,
+			OriginalCurrency: price.Currency,
+			FXRate:           price.Rate,
+		}
+
+		for _, s := range r.Stops {
+			f.Layovers = append(f.Layovers, domain.Layover{Airport: s.Airport, DurationMin: s.WaitTimeMinutes})
+		}
+		f.Segments = routeSegments(f)
+		f.FlyingMin, f.GroundMin = common.SplitTravelTime(f)
+		flights = append(flights, f)
+	}
+
+	result.Flights = flights
+	return result, nil
+}
+
+// airAsiaAllowance matches a weight clause of AirAsia's baggage_note, e.g.
+// "7kg cabin baggage" or "20kg checked baggage included".
+var airAsiaAllowance = regexp.MustCompile(`^(\d+)\s*kg (cabin|checked) baggage( included)?$`)
+
+// airAsiaBaggage reads AirAsia's baggage_note, comma separated clauses such
+// as "Cabin baggage only, checked bags additional fee" or "7kg cabin
+// baggage, 20kg checked baggage included". An unknown clause leaves the
+// whole note unparsed.
+func airAsiaBaggage(note string) (domain.Baggage, bool) {
+	b := domain.Baggage{Note: note}
+	if strings.TrimSpace(note) == "" {
+		return b, false
+	}
+
+	for _, clause := range strings.Split(strings.ToLower(note), ",") {
+		clause = strings.TrimSpace(clause)
+
+		switch clause {
+		case "cabin baggage only", "checked bags additional fee":
+			continue
+		}
+
+		m := airAsiaAllowance.FindStringSubmatch(clause)
+		if m == nil {
+			return domain.Baggage{Note: note}, false
+		}
+		kg, _ := strconv.Atoi(m[1])
+		if m[2] == "cabin" {
+			b.CabinKg = kg
+		} else {
+			b.CheckedKg = kg
+		}
 	}
 
-	return flights, nil
+	b.CheckedIncluded = b.CheckedKg > 0
+	b.Parsed = true
+	return b, true
 }
@@ -0,0 +1,30 @@
+package provider
+
+import (
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// kgAmount matches a bare weight such as "20 kg".
+var kgAmount = regexp.MustCompile(`^(\d+)\s*kg$`)
+
+// parseKg reads a bare weight; an empty value is 0 kg.
+func parseKg(value string) (int, bool) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return 0, true
+	}
+	m := kgAmount.FindStringSubmatch(value)
+	if m == nil {
+		return 0, false
+	}
+	n, _ := strconv.Atoi(m[1])
+	return n, true
+}
+
+// logUnparsedBaggage records a baggage allowance an adapter could not read.
+func logUnparsedBaggage(provider, flightCode, text string) {
+	log.Printf("[WARN] provider %s flight %s: unparsed baggage %q", provider, flightCode, text)
+}
@@ -0,0 +1,145 @@
+package provider
+
+import (
+	"testing"
+
+	"bookcabin/internal/domain"
+)
+
+func TestAirAsiaBaggage(t *testing.T) {
+	tests := []struct {
+		note string
+		want domain.Baggage
+		ok   bool
+	}{
+		{
+			note: "Cabin baggage only, checked bags additional fee",
+			want: domain.Baggage{Parsed: true},
+			ok:   true,
+		},
+		{
+			note: "7kg cabin baggage, 20kg checked baggage included",
+			want: domain.Baggage{CabinKg: 7, CheckedKg: 20, CheckedIncluded: true, Parsed: true},
+			ok:   true,
+		},
+		{
+			note: "7 KG cabin baggage",
+			want: domain.Baggage{CabinKg: 7, Parsed: true},
+			ok:   true,
+		},
+		{note: ""},
+		{note: "   "},
+		{note: "7kg cabin baggage, free snacks"},
+		{note: "seven kg cabin baggage"},
+	}
+
+	for _, tt := range tests {
+		got, ok := airAsiaBaggage(tt.note)
+		tt.want.Note = tt.note
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("airAsiaBaggage(%q) = %+v, %t; want %+v, %t", tt.note, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestBatikBaggage(t *testing.T) {
+	tests := []struct {
+		info string
+		want domain.Baggage
+		ok   bool
+	}{
+		{
+			info: "7kg cabin, 20kg checked",
+			want: domain.Baggage{CabinKg: 7, CheckedKg: 20, CheckedIncluded: true, Parsed: true},
+			ok:   true,
+		},
+		{
+			info: " 7 KG cabin ",
+			want: domain.Baggage{CabinKg: 7, Parsed: true},
+			ok:   true,
+		},
+		{info: ""},
+		{info: "20kg checked"},
+		{info: "7kg cabin, 1 piece checked"},
+	}
+
+	for _, tt := range tests {
+		got, ok := batikBaggage(tt.info)
+		tt.want.Note = tt.info
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("batikBaggage(%q) = %+v, %t; want %+v, %t", tt.info, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestLionBaggage(t *testing.T) {
+	tests := []struct {
+		raw  LionBaggage
+		want domain.Baggage
+		ok   bool
+	}{
+		{
+			raw:  LionBaggage{Cabin: "7 kg", Hold: "20 kg"},
+			want: domain.Baggage{CabinKg: 7, CheckedKg: 20, CheckedIncluded: true, Parsed: true, Note: "7 kg cabin, 20 kg checked"},
+			ok:   true,
+		},
+		{
+			raw:  LionBaggage{Cabin: "7kg"},
+			want: domain.Baggage{CabinKg: 7, Parsed: true, Note: "7kg cabin"},
+			ok:   true,
+		},
+		{
+			raw:  LionBaggage{},
+			want: domain.Baggage{Parsed: true},
+			ok:   true,
+		},
+		{
+			raw:  LionBaggage{Cabin: "7 kg", Hold: "1 piece"},
+			want: domain.Baggage{Note: "7 kg cabin, 1 piece checked"},
+		},
+		{
+			raw:  LionBaggage{Cabin: "small bag", Hold: "20 kg"},
+			want: domain.Baggage{Note: "small bag cabin, 20 kg checked"},
+		},
+	}
+
+	for _, tt := range tests {
+		got, ok := lionBaggage(tt.raw)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("lionBaggage(%+v) = %+v, %t; want %+v, %t", tt.raw, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestGarudaBaggage(t *testing.T) {
+	tests := []struct {
+		raw  GarudaBaggage
+		want domain.Baggage
+		ok   bool
+	}{
+		{
+			raw:  GarudaBaggage{CarryOn: 1, Checked: 2},
+			want: domain.Baggage{CabinPieces: 1, CheckedPieces: 2, CheckedIncluded: true, Parsed: true},
+			ok:   true,
+		},
+		{
+			raw:  GarudaBaggage{CarryOn: 1},
+			want: domain.Baggage{CabinPieces: 1, Parsed: true},
+			ok:   true,
+		},
+		{
+			raw:  GarudaBaggage{},
+			want: domain.Baggage{Parsed: true},
+			ok:   true,
+		},
+		{raw: GarudaBaggage{CarryOn: -1, Checked: 1}},
+		{raw: GarudaBaggage{CarryOn: 1, Checked: -1}},
+	}
+
+	for _, tt := range tests {
+		got, ok := garudaBaggage(tt.raw)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("garudaBaggage(%+v) = %+v, %t; want %+v, %t", tt.raw, got, ok, tt.want, tt.ok)
+		}
+	}
+}
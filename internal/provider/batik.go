@@ -3,9 +3,13 @@ package provider
 import (
 	"bookcabin/internal/common"
 	"bookcabin/internal/domain"
+	"context"
 	"encoding/json"
-	"errors"
+	"fmt"
 	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
 )
 
 // ===== RAW BATIK RESPONSE =====
@@ -39,44 +43,83 @@ type BatikAirConnection struct {
 }
 
 type BatikAirFare struct {
-	BasePrice  int    `json:"basePrice"`
-	Taxes      int    `json:"taxes"`
-	TotalPrice int    `json:"totalPrice"`
-	Currency   string `json:"currencyCode"`
-	Class      string `json:"class"`
+	BasePrice  json.Number `json:"basePrice"`
+	Taxes      json.Number `json:"taxes"`
+	TotalPrice json.Number `json:"totalPrice"`
+	Currency   string      `json:"currencyCode"`
+	Class      string      `json:"class"`
 }
 
 type BatikProvider struct {
 	BaseURL string
 	Client  *http.Client
+	Retry   RetryPolicy
+	FX      common.RateLookup
 }
 
 func (b *BatikProvider) Name() string { return "Batik Air" }
 
-func (b *BatikProvider) Search(req domain.SearchRequest) ([]domain.Flight, error) {
-	resp, err := b.Client.Get(b.BaseURL + "/batik/search")
+func (b *BatikProvider) Search(ctx context.Context, req domain.SearchRequest) (domain.ProviderResult, error) {
+	resp, attempts, err := getWithRetry(ctx, b.Client, b.Retry, b.Name(), b.BaseURL+"/batik/search")
+	result := domain.ProviderResult{Attempts: attempts}
 	if err != nil {
-		return nil, err
+		return result, err
 	}
 	defer resp.Body.Close()
 
 	var batikRaw BatikAirResponse
 	if err := json.NewDecoder(resp.Body).Decode(&batikRaw); err != nil {
-		return nil, err
+		return result, fmt.Errorf("%w: %v", domain.ErrProviderDecode, err)
 	}
 
 	if batikRaw.Code != CodeSuccess {
-		return nil, errors.New("batik air api returned failure")
+		return result, fmt.Errorf("batik air api returned failure: %w", domain.ErrProviderBusiness)
 	}
 
+	result.RawResults = len(batikRaw.Results)
+
 	flights := make([]domain.Flight, 0)
 	for _, r := range batikRaw.Results {
-		dep, _ := common.ParseFlexibleTime(r.DepartureDateTime)
-		arr, _ := common.ParseFlexibleTime(r.ArrivalDateTime)
+		dep, err := common.ParseFlexibleTime(r.DepartureDateTime)
+		if err != nil {
+			result.DroppedNormalization++
+			continue
+		}
+
+		arr, err := common.ParseFlexibleTime(r.ArrivalDateTime)
+		if err != nil {
+			result.DroppedNormalization++
+			continue
+		}
+
+		price, err := common.ParsePriceToIDR(r.Fare.TotalPrice, r.Fare.Currency, b.FX)
+		if err != nil {
+			result.DroppedNormalization++
+			continue
+		}
+
+		// travelTime is Batik's own duration; the timestamps are the fallback
+		duration, err := common.ParseTravelTime(r.TravelTime)
+		if err != nil {
+			duration = int(arr.Sub(dep).Minutes())
+		}
 
-		price, _ := common.ParsePriceToIDR(r.Fare.TotalPrice, r.Fare.Currency)
+		// Batik quotes base and taxes next to the total; fall back to an
+		// estimate when either is missing
+		fare := common.EstimateFare(price.IDR, req.Passengers)
+		base, baseErr := common.ParsePriceToIDR(r.Fare.BasePrice, r.Fare.Currency, b.FX)
+		taxes, taxErr := common.ParsePriceToIDR(r.Fare.Taxes, r.Fare.Currency, b.FX)
+		if baseErr == nil && taxErr == nil {
+			fare = common.NewFare(base.IDR, taxes.IDR, price.IDR, req.Passengers)
+		}
 
-		flights = append(flights, domain.Flight{
+		baggage, ok := batikBaggage(r.BaggageInfo)
+		if !ok {
+			result.UnparsedBaggage++
+			logUnparsedBaggage(b.Name(), r.FlightNumber, r.BaggageInfo)
+		}
+
+		f := domain.Flight{
 			FlightCode:     r.FlightNumber,
 			Airline:        r.AirlineName,
 			AirlineCode:    r.AirlineIATA,
@@ -84,15 +127,54 @@ func (b *BatikProvider) Search(req domain.SearchRequest) ([]domain.Flight, error
 			Destination:    r.Destination,
 			DepartureTime:  dep,
 			ArrivalTime:    arr,
-			DurationMin:    int(arr.Sub(dep).Minutes()),
+			DurationMin:    duration,
 			Stops:          r.NumberOfStops,
-			PriceIDR:       price,
+			PriceIDR:       price.IDR,
+			Fare:           fare,
 			AvailableSeats: r.SeatsAvailable,
+			Cabin:          common.NormalizeCabin(r.Fare.Class),
 			Aircraft:       r.AircraftModel,
-			Baggage:        r.BaggageInfo,
+			Baggage:        baggage,
 			Amenities:      r.OnboardServices,
-		})
+
+			OriginalAmount:   price.Amount,
+			OriginalCurrency: price.Currency,
+			FXRate:           price.Rate,
+		}
+
+		for _, c := range r.Connections {
+			// an unreadable stop duration still tells where the stop is
+			wait, _ := common.ParseTravelTime(c.StopDuration)
+			f.Layovers = append(f.Layovers, domain.Layover{Airport: c.StopAirport, DurationMin: wait})
+		}
+		f.Segments = routeSegments(f)
+		f.FlyingMin, f.GroundMin = common.SplitTravelTime(f)
+		flights = append(flights, f)
 	}
 
-	return flights, nil
+	result.Flights = flights
+	return result, nil
+}
+
+// batikAllowance matches Batik's baggageInfo: a cabin weight, optionally
+// followed by a checked weight.
+var batikAllowance = regexp.MustCompile(`^(\d+)\s*kg cabin(?:,\s*(\d+)\s*kg checked)?$`)
+
+// batikBaggage reads Batik's baggageInfo, e.g. "7kg cabin, 20kg checked" or
+// "7kg cabin" for fares without a checked bag.
+func batikBaggage(info string) (domain.Baggage, bool) {
+	b := domain.Baggage{Note: info}
+
+	m := batikAllowance.FindStringSubmatch(strings.ToLower(strings.TrimSpace(info)))
+	if m == nil {
+		return b, false
+	}
+
+	b.CabinKg, _ = strconv.Atoi(m[1])
+	if m[2] != "" {
+		b.CheckedKg, _ = strconv.Atoi(m[2])
+	}
+	b.CheckedIncluded = b.CheckedKg > 0
+	b.Parsed = true
+	return b, true
 }
@@ -3,8 +3,9 @@ package provider
 import (
 	"bookcabin/internal/common"
 	"bookcabin/internal/domain"
+	"context"
 	"encoding/json"
-	"errors"
+	"fmt"
 	"io"
 	"net/http"
 )
@@ -39,8 +40,8 @@ type GarudaLocation struct {
 }
 
 type GarudaPrice struct {
-	Amount   int    `json:"amount"`
-	Currency string `json:"currency"`
+	Amount   json.Number `json:"amount"`
+	Currency string      `json:"currency"`
 }
 
 type GarudaBaggage struct {
@@ -64,38 +65,63 @@ type SegmentPoint struct {
 type GarudaProvider struct {
 	BaseURL string
 	Client  *http.Client
+	Retry   RetryPolicy
+	FX      common.RateLookup
 }
 
 func (g *GarudaProvider) Name() string { return "Garuda Indonesia" }
 
-func (g *GarudaProvider) Search(req domain.SearchRequest) ([]domain.Flight, error) {
+func (g *GarudaProvider) Search(ctx context.Context, req domain.SearchRequest) (domain.ProviderResult, error) {
 	var garudaRaw GarudaResponse
-	resp, err := g.Client.Get(g.BaseURL + "/garuda/search")
+	resp, attempts, err := getWithRetry(ctx, g.Client, g.Retry, g.Name(), g.BaseURL+"/garuda/search")
+	result := domain.ProviderResult{Attempts: attempts}
 	if err != nil {
-		return nil, err
+		return result, err
 	}
 	defer resp.Body.Close()
 
 	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return result, err
 	}
 
 	if err := json.Unmarshal(bodyBytes, &garudaRaw); err != nil {
-		return nil, err
+		return result, fmt.Errorf("%w: %v", domain.ErrProviderDecode, err)
 	}
 
 	if garudaRaw.Status != StatusSuccess {
-		return nil, errors.New("garuda api returned failure")
+		return result, fmt.Errorf("garuda api returned failure: %w", domain.ErrProviderBusiness)
 	}
 
+	result.RawResults = len(garudaRaw.Flights)
+
 	flights := make([]domain.Flight, 0)
 	for _, r := range garudaRaw.Flights {
-		dep, _ := common.ParseFlexibleTime(r.Departure.Time)
-		arr, _ := common.ParseFlexibleTime(r.Arrival.Time)
-		price, _ := common.ParsePriceToIDR(r.Price.Amount, r.Price.Currency)
-
-		flights = append(flights, domain.Flight{
+		dep, err := common.ParseFlexibleTime(r.Departure.Time)
+		if err != nil {
+			result.DroppedNormalization++
+			continue
+		}
+
+		arr, err := common.ParseFlexibleTime(r.Arrival.Time)
+		if err != nil {
+			result.DroppedNormalization++
+			continue
+		}
+
+		price, err := common.ParsePriceToIDR(r.Price.Amount, r.Price.Currency, g.FX)
+		if err != nil {
+			result.DroppedNormalization++
+			continue
+		}
+
+		baggage, ok := garudaBaggage(r.Baggage)
+		if !ok {
+			result.UnparsedBaggage++
+			logUnparsedBaggage(g.Name(), r.FlightID, fmt.Sprintf("%+v", r.Baggage))
+		}
+
+		f := domain.Flight{
 			FlightCode:     r.FlightID,
 			Airline:        r.Airline,
 			AirlineCode:    r.AirlineCode,
@@ -105,12 +131,92 @@ func (g *GarudaProvider) Search(req domain.SearchRequest) ([]domain.Flight, erro
 			ArrivalTime:    arr,
 			DurationMin:    r.DurationMin,
 			Stops:          r.Stops,
-			PriceIDR:       price,
+			PriceIDR:       price.IDR,
+			Fare:           common.EstimateFare(price.IDR, req.Passengers),
 			AvailableSeats: r.AvailableSeats,
+			Cabin:          common.NormalizeCabin(r.FareClass),
 			Aircraft:       r.Aircraft,
+			Baggage:        baggage,
 			Amenities:      r.Amenities,
+
+			OriginalAmount:   price.Amount,
+			OriginalCurrency: price.Currency,
+			FXRate:           price.Rate,
+		}
+
+		f.Segments, f.Layovers = garudaSegments(r.Segments, f)
+		if f.Segments == nil {
+			f.Segments = routeSegments(f)
+		}
+		f.FlyingMin, f.GroundMin = common.SplitTravelTime(f)
+		flights = append(flights, f)
+	}
+
+	result.Flights = flights
+	return result, nil
+}
+
+// garudaSegments converts Garuda's segment list. The layover before a
+// segment is its layover_minutes, or the gap to the previous arrival when
+// that is missing. It returns nil when the segments are unreadable or do not
+// chain from the flight's origin to its destination.
+func garudaSegments(raw []GarudaSegment, f domain.Flight) ([]domain.Segment, []domain.Layover) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	var (
+		segments []domain.Segment
+		layovers []domain.Layover
+	)
+	from := f.Origin
+	for i, s := range raw {
+		dep, err := common.ParseFlexibleTime(s.Departure.Time)
+		if err != nil {
+			return nil, nil
+		}
+		arr, err := common.ParseFlexibleTime(s.Arrival.Time)
+		if err != nil {
+			return nil, nil
+		}
+		if s.Departure.Airport != from {
+			return nil, nil
+		}
+
+		if i > 0 {
+			wait := s.LayoverMinutes
+			if wait == 0 {
+				wait = int(dep.Sub(segments[i-1].ArrivalTime).Minutes())
+			}
+			layovers = append(layovers, domain.Layover{Airport: from, DurationMin: wait})
+		}
+
+		segments = append(segments, domain.Segment{
+			FlightCode:    s.FlightNumber,
+			Origin:        s.Departure.Airport,
+			Destination:   s.Arrival.Airport,
+			DepartureTime: dep,
+			ArrivalTime:   arr,
+			DurationMin:   s.DurationMinutes,
 		})
+		from = s.Arrival.Airport
+	}
+
+	if from != f.Destination {
+		return nil, nil
 	}
+	return segments, layovers
+}
 
-	return flights, nil
+// garudaBaggage reads Garuda's piece counts; Garuda gives no weights.
+func garudaBaggage(raw GarudaBaggage) (domain.Baggage, bool) {
+	if raw.CarryOn < 0 || raw.Checked < 0 {
+		return domain.Baggage{}, false
+	}
+	return domain.Baggage{
+		CabinPieces:     raw.CarryOn,
+		CheckedPieces:   raw.Checked,
+		CheckedIncluded: raw.Checked > 0,
+		Parsed:          true,
+	}, true
 }
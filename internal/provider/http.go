@@ -1,7 +1,13 @@
 package provider
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
 	"net/http"
+	"strconv"
 	"time"
 )
 
@@ -16,3 +22,118 @@ func NewHTTPClient() *http.Client {
 		Timeout: 2 * time.Second,
 	}
 }
+
+// RetryPolicy controls how a provider retries transient failures: connection
+// errors, 5xx and 429 responses. The zero value makes a single attempt.
+// MaxDelay only caps the backoff: a Retry-After from the provider is waited
+// in full, and when it does not fit before the context deadline the call
+// gives up instead of retrying early.
+type RetryPolicy struct {
+	MaxAttempts int           // total attempts, including the first one
+	BaseDelay   time.Duration // delay before the first retry, doubled every attempt
+	MaxDelay    time.Duration // cap for a single backoff delay
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   100 * time.Millisecond,
+		MaxDelay:    1 * time.Second,
+	}
+}
+
+// backoff returns the delay before the given retry (1-based) using capped
+// exponential backoff with equal jitter.
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	d := p.BaseDelay << (retry - 1)
+	if d <= 0 || (p.MaxDelay > 0 && d > p.MaxDelay) {
+		d = p.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	half := d / 2
+	return half + rand.N(half+1)
+}
+
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected http status %d", e.StatusCode)
+}
+
+func isRetryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= 500
+}
+
+// getWithRetry issues a GET request, retrying transient failures according to
+// policy without ever sleeping past the context deadline. It returns the
+// number of attempts made alongside the response.
+func getWithRetry(
+	ctx context.Context,
+	client *http.Client,
+	policy RetryPolicy,
+	provider, url string,
+) (*http.Response, int, error) {
+
+	for attempt := 1; ; attempt++ {
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, attempt, err
+		}
+
+		resp, err := client.Do(httpReq)
+		var retryAfter time.Duration
+		switch {
+		case err != nil:
+			if ctx.Err() != nil {
+				return nil, attempt, err
+			}
+		case resp.StatusCode >= 200 && resp.StatusCode < 300:
+			if attempt > 1 {
+				log.Printf("[INFO] provider %s succeeded after %d attempts", provider, attempt)
+			}
+			return resp, attempt, nil
+		default:
+			retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+
+			err = &StatusError{StatusCode: resp.StatusCode}
+			if !isRetryableStatus(resp.StatusCode) {
+				return nil, attempt, err
+			}
+		}
+
+		if attempt >= policy.MaxAttempts {
+			return nil, attempt, err
+		}
+
+		delay := max(policy.backoff(attempt), retryAfter)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= delay {
+			return nil, attempt, err
+		}
+
+		log.Printf("[WARN] provider %s attempt %d/%d failed: %v, retrying in %s",
+			provider, attempt, policy.MaxAttempts, err, delay)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, attempt, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// parseRetryAfter supports the delay-seconds form of the Retry-After header.
+func parseRetryAfter(v string) time.Duration {
+	secs, err := strconv.Atoi(v)
+	if err != nil || secs < 0 {
+		return 0
+	}
+	return time.Duration(secs) * time.Second
+}
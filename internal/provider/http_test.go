@@ -0,0 +1,177 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// scriptedServer answers the n-th request with statuses[n] (the last status
+// repeats) and sets Retry-After on every non-2xx answer when given.
+func scriptedServer(t *testing.T, retryAfter string, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1)) - 1
+		status := statuses[min(n, len(statuses)-1)]
+		if status >= 300 && retryAfter != "" {
+			w.Header().Set("Retry-After", retryAfter)
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestGetWithRetry(t *testing.T) {
+	fast := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+
+	tests := []struct {
+		name         string
+		statuses     []int
+		retryAfter   string
+		policy       RetryPolicy
+		timeout      time.Duration
+		wantAttempts int
+		wantStatus   int // 0 means success
+		minElapsed   time.Duration
+		maxElapsed   time.Duration
+	}{
+		{
+			name:         "success on first attempt",
+			statuses:     []int{200},
+			policy:       fast,
+			wantAttempts: 1,
+		},
+		{
+			name:         "retries 5xx until success",
+			statuses:     []int{503, 500, 200},
+			policy:       fast,
+			wantAttempts: 3,
+		},
+		{
+			name:         "gives up after max attempts",
+			statuses:     []int{500},
+			policy:       fast,
+			wantAttempts: 3,
+			wantStatus:   500,
+		},
+		{
+			name:         "does not retry 4xx",
+			statuses:     []int{404, 200},
+			policy:       fast,
+			wantAttempts: 1,
+			wantStatus:   404,
+		},
+		{
+			name:         "zero policy makes a single attempt",
+			statuses:     []int{503, 200},
+			wantAttempts: 1,
+			wantStatus:   503,
+		},
+		{
+			name:         "waits the full Retry-After beyond MaxDelay",
+			statuses:     []int{429, 200},
+			retryAfter:   "1",
+			policy:       fast,
+			timeout:      3 * time.Second,
+			wantAttempts: 2,
+			minElapsed:   time.Second,
+		},
+		{
+			name:         "gives up when Retry-After does not fit the deadline",
+			statuses:     []int{503, 200},
+			retryAfter:   "5",
+			policy:       fast,
+			timeout:      500 * time.Millisecond,
+			wantAttempts: 1,
+			wantStatus:   503,
+			maxElapsed:   400 * time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, calls := scriptedServer(t, tt.retryAfter, tt.statuses...)
+
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+
+			start := time.Now()
+			resp, attempts, err := getWithRetry(ctx, srv.Client(), tt.policy, "test", srv.URL)
+			elapsed := time.Since(start)
+			if resp != nil {
+				resp.Body.Close()
+			}
+
+			if attempts != tt.wantAttempts || int(calls.Load()) != tt.wantAttempts {
+				t.Fatalf("attempts = %d (server saw %d), want %d", attempts, calls.Load(), tt.wantAttempts)
+			}
+
+			var statusErr *StatusError
+			switch {
+			case tt.wantStatus == 0 && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantStatus != 0 && (!errors.As(err, &statusErr) || statusErr.StatusCode != tt.wantStatus):
+				t.Fatalf("err = %v, want status %d", err, tt.wantStatus)
+			}
+
+			if elapsed < tt.minElapsed {
+				t.Fatalf("returned after %s, want at least %s", elapsed, tt.minElapsed)
+			}
+			if tt.maxElapsed > 0 && elapsed > tt.maxElapsed {
+				t.Fatalf("returned after %s, want at most %s", elapsed, tt.maxElapsed)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	tests := []struct {
+		retry    int
+		min, max time.Duration
+	}{
+		{retry: 1, min: 50 * time.Millisecond, max: 100 * time.Millisecond},
+		{retry: 2, min: 100 * time.Millisecond, max: 200 * time.Millisecond},
+		{retry: 4, min: 400 * time.Millisecond, max: 800 * time.Millisecond},
+		{retry: 5, min: 500 * time.Millisecond, max: time.Second},
+		{retry: 60, min: 500 * time.Millisecond, max: time.Second},
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 50; i++ {
+			if d := p.backoff(tt.retry); d < tt.min || d > tt.max {
+				t.Fatalf("backoff(%d) = %s, want within [%s, %s]", tt.retry, d, tt.min, tt.max)
+			}
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"", 0},
+		{"0", 0},
+		{"3", 3 * time.Second},
+		{"-1", 0},
+		{"Wed, 21 Oct 2015 07:28:00 GMT", 0},
+	}
+
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.in); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
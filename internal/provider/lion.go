@@ -3,9 +3,11 @@ package provider
 import (
 	"bookcabin/internal/common"
 	"bookcabin/internal/domain"
+	"context"
 	"encoding/json"
-	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 )
 
 type LionResponse struct {
@@ -56,9 +58,9 @@ type LionSchedule struct {
 }
 
 type LionPricing struct {
-	Total    int    `json:"total"`
-	Currency string `json:"currency"`
-	FareType string `json:"fare_type"`
+	Total    json.Number `json:"total"`
+	Currency string      `json:"currency"`
+	FareType string      `json:"fare_type"`
 }
 
 type LionServices struct {
@@ -80,28 +82,33 @@ type LionLayover struct {
 type LionAirProvider struct {
 	BaseURL string
 	Client  *http.Client
+	Retry   RetryPolicy
+	FX      common.RateLookup
 }
 
 func (l *LionAirProvider) Name() string {
 	return "Lion Air"
 }
 
-func (l *LionAirProvider) Search(req domain.SearchRequest) ([]domain.Flight, error) {
+func (l *LionAirProvider) Search(ctx context.Context, req domain.SearchRequest) (domain.ProviderResult, error) {
 	var lionRaw LionResponse
-	resp, err := l.Client.Get(l.BaseURL + "/lion/search")
+	resp, attempts, err := getWithRetry(ctx, l.Client, l.Retry, l.Name(), l.BaseURL+"/lion/search")
+	result := domain.ProviderResult{Attempts: attempts}
 	if err != nil {
-		return nil, err
+		return result, err
 	}
 	defer resp.Body.Close()
 
 	if err := json.NewDecoder(resp.Body).Decode(&lionRaw); err != nil {
-		return nil, err
+		return result, fmt.Errorf("%w: %v", domain.ErrProviderDecode, err)
 	}
 
 	if !lionRaw.Success {
-		return nil, errors.New("lion air api returned failure")
+		return result, fmt.Errorf("lion air api returned failure: %w", domain.ErrProviderBusiness)
 	}
 
+	result.RawResults = len(lionRaw.Data.AvailableFlights)
+
 	flights := []domain.Flight{}
 	for _, r := range lionRaw.Data.AvailableFlights {
 
@@ -110,6 +117,7 @@ func (l *LionAirProvider) Search(req domain.SearchRequest) ([]domain.Flight, err
 			r.Schedule.DepartureTimezone,
 		)
 		if err != nil {
+			result.DroppedNormalization++
 			continue
 		}
 
@@ -118,14 +126,17 @@ func (l *LionAirProvider) Search(req domain.SearchRequest) ([]domain.Flight, err
 			r.Schedule.ArrivalTimezone,
 		)
 		if err != nil {
+			result.DroppedNormalization++
 			continue
 		}
 
-		priceIDR, err := common.ParsePriceToIDR(
+		price, err := common.ParsePriceToIDR(
 			r.Pricing.Total,
 			r.Pricing.Currency,
+			l.FX,
 		)
 		if err != nil {
+			result.DroppedNormalization++
 			continue
 		}
 
@@ -134,7 +145,13 @@ func (l *LionAirProvider) Search(req domain.SearchRequest) ([]domain.Flight, err
 			stops = r.StopCount
 		}
 
-		flights = append(flights, domain.Flight{
+		baggage, ok := lionBaggage(r.Services.Baggage)
+		if !ok {
+			result.UnparsedBaggage++
+			logUnparsedBaggage(l.Name(), r.ID, baggage.Note)
+		}
+
+		f := domain.Flight{
 			FlightCode:     r.ID,
 			Airline:        r.Carrier.Name,
 			AirlineCode:    r.Carrier.IATA,
@@ -144,13 +161,53 @@ func (l *LionAirProvider) Search(req domain.SearchRequest) ([]domain.Flight, err
 			ArrivalTime:    arr,
 			DurationMin:    r.FlightTime,
 			Stops:          stops,
-			PriceIDR:       priceIDR,
+			PriceIDR:       price.IDR,
+			Fare:           common.EstimateFare(price.IDR, req.Passengers),
 			AvailableSeats: r.SeatsLeft,
+			Cabin:          common.NormalizeCabin(r.Pricing.FareType),
 			Aircraft:       r.PlaneType,
-			Baggage: r.Services.Baggage.Cabin + " cabin, " +
-				r.Services.Baggage.Hold + " checked",
-		})
+			Baggage:        baggage,
+
+			OriginalAmount:   price.Amount,
+			OriginalCurrency: price.Currency,
+			FXRate:           price.Rate,
+		}
+
+		for _, l := range r.Layovers {
+			f.Layovers = append(f.Layovers, domain.Layover{Airport: l.Airport, DurationMin: l.DurationMinutes})
+		}
+		f.Segments = routeSegments(f)
+		f.FlyingMin, f.GroundMin = common.SplitTravelTime(f)
+		flights = append(flights, f)
+	}
+
+	result.Flights = flights
+	return result, nil
+}
+
+// lionBaggage reads Lion's cabin and hold weights, e.g. "7 kg" and "20 kg";
+// an empty hold means no checked bag.
+func lionBaggage(raw LionBaggage) (domain.Baggage, bool) {
+	var parts []string
+	if cabin := strings.TrimSpace(raw.Cabin); cabin != "" {
+		parts = append(parts, cabin+" cabin")
+	}
+	if hold := strings.TrimSpace(raw.Hold); hold != "" {
+		parts = append(parts, hold+" checked")
+	}
+	b := domain.Baggage{Note: strings.Join(parts, ", ")}
+
+	cabin, ok := parseKg(raw.Cabin)
+	if !ok {
+		return b, false
+	}
+	hold, ok := parseKg(raw.Hold)
+	if !ok {
+		return b, false
 	}
 
-	return flights, nil
+	b.CabinKg, b.CheckedKg = cabin, hold
+	b.CheckedIncluded = hold > 0
+	b.Parsed = true
+	return b, true
 }
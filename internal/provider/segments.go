@@ -0,0 +1,39 @@
+package provider
+
+import (
+	"bookcabin/internal/domain"
+)
+
+// routeSegments derives the segments of a flight from its layovers for
+// providers that only list the connection airports. Every segment carries
+// the marketed flight number; times are only known for the first departure
+// and the last arrival, and a direct flight is a single full segment. It
+// returns nil when the layovers do not account for every stop, rather than
+// guess where the flight lands.
+func routeSegments(f domain.Flight) []domain.Segment {
+	if len(f.Layovers) != f.Stops {
+		return nil
+	}
+	if len(f.Layovers) == 0 {
+		return []domain.Segment{{
+			FlightCode:    f.FlightCode,
+			Origin:        f.Origin,
+			Destination:   f.Destination,
+			DepartureTime: f.DepartureTime,
+			ArrivalTime:   f.ArrivalTime,
+			DurationMin:   f.DurationMin,
+		}}
+	}
+
+	segments := make([]domain.Segment, 0, len(f.Layovers)+1)
+	from := f.Origin
+	for _, l := range f.Layovers {
+		segments = append(segments, domain.Segment{FlightCode: f.FlightCode, Origin: from, Destination: l.Airport})
+		from = l.Airport
+	}
+	segments = append(segments, domain.Segment{FlightCode: f.FlightCode, Origin: from, Destination: f.Destination})
+
+	segments[0].DepartureTime = f.DepartureTime
+	segments[len(segments)-1].ArrivalTime = f.ArrivalTime
+	return segments
+}
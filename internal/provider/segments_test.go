@@ -0,0 +1,201 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"bookcabin/internal/domain"
+)
+
+// route lists the airports a flight's segments pass through, or nil when
+// it has no segments.
+func route(segments []domain.Segment) []string {
+	if len(segments) == 0 {
+		return nil
+	}
+	airports := []string{segments[0].Origin}
+	for _, s := range segments {
+		airports = append(airports, s.Destination)
+	}
+	return airports
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestAirAsiaSegments(t *testing.T) {
+	base := AirAsiaFlight{
+		FlightCode:   "QZ7250",
+		Airline:      "AirAsia",
+		FromAirport:  "CGK",
+		ToAirport:    "DPS",
+		DepartTime:   "2025-12-15T15:15:00+07:00",
+		ArriveTime:   "2025-12-15T20:35:00+08:00",
+		DurationHrs:  4.33,
+		DirectFlight: true,
+		PriceIDR:     485000,
+		Seats:        88,
+		BaggageNote:  "Cabin baggage only",
+	}
+
+	tests := []struct {
+		name      string
+		direct    bool
+		stops     []AirAsiaStop
+		wantStops int
+		wantRoute []string
+	}{
+		{
+			name:      "direct",
+			direct:    true,
+			wantStops: 0,
+			wantRoute: []string{"CGK", "DPS"},
+		},
+		{
+			name:      "one stop",
+			stops:     []AirAsiaStop{{Airport: "SOC", WaitTimeMinutes: 95}},
+			wantStops: 1,
+			wantRoute: []string{"CGK", "SOC", "DPS"},
+		},
+		{
+			name:      "two stops",
+			stops:     []AirAsiaStop{{Airport: "SOC", WaitTimeMinutes: 60}, {Airport: "SUB", WaitTimeMinutes: 45}},
+			wantStops: 2,
+			wantRoute: []string{"CGK", "SOC", "SUB", "DPS"},
+		},
+		{
+			name:      "connecting without a stop list",
+			wantStops: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw := base
+			raw.DirectFlight, raw.Stops = tt.direct, tt.stops
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				json.NewEncoder(w).Encode(AirAsiaResponse{Status: StatusOK, Flights: []AirAsiaFlight{raw}})
+			}))
+			defer srv.Close()
+
+			p := &AirAsiaProvider{BaseURL: srv.URL, Client: srv.Client()}
+			res, err := p.Search(context.Background(), domain.SearchRequest{Passengers: 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(res.Flights) != 1 {
+				t.Fatalf("got %d flights, want 1", len(res.Flights))
+			}
+
+			f := res.Flights[0]
+			if f.Stops != tt.wantStops || len(f.Layovers) != len(tt.stops) {
+				t.Fatalf("stops = %d with %d layovers, want %d with %d", f.Stops, len(f.Layovers), tt.wantStops, len(tt.stops))
+			}
+			if got := route(f.Segments); !equalStrings(got, tt.wantRoute) {
+				t.Fatalf("segments route = %v, want %v", got, tt.wantRoute)
+			}
+		})
+	}
+}
+
+func TestGarudaSegments(t *testing.T) {
+	flight := domain.Flight{Origin: "CGK", Destination: "DPS"}
+	seg := func(from, dep, to, arr string, layover int) GarudaSegment {
+		return GarudaSegment{
+			FlightNumber:   "GA" + from,
+			Departure:      SegmentPoint{Airport: from, Time: dep},
+			Arrival:        SegmentPoint{Airport: to, Time: arr},
+			LayoverMinutes: layover,
+		}
+	}
+
+	tests := []struct {
+		name         string
+		raw          []GarudaSegment
+		wantRoute    []string
+		wantLayovers []int
+	}{
+		{
+			name:      "no segments",
+			wantRoute: nil,
+		},
+		{
+			name:      "direct",
+			raw:       []GarudaSegment{seg("CGK", "2025-12-15T06:00:00+07:00", "DPS", "2025-12-15T08:50:00+08:00", 0)},
+			wantRoute: []string{"CGK", "DPS"},
+		},
+		{
+			name: "layover given",
+			raw: []GarudaSegment{
+				seg("CGK", "2025-12-15T06:00:00+07:00", "SUB", "2025-12-15T07:30:00+07:00", 0),
+				seg("SUB", "2025-12-15T08:30:00+07:00", "DPS", "2025-12-15T10:30:00+08:00", 75),
+			},
+			wantRoute:    []string{"CGK", "SUB", "DPS"},
+			wantLayovers: []int{75},
+		},
+		{
+			name: "layover from the gap",
+			raw: []GarudaSegment{
+				seg("CGK", "2025-12-15T06:00:00+07:00", "SUB", "2025-12-15T07:30:00+07:00", 0),
+				seg("SUB", "2025-12-15T08:30:00+07:00", "DPS", "2025-12-15T10:30:00+08:00", 0),
+			},
+			wantRoute:    []string{"CGK", "SUB", "DPS"},
+			wantLayovers: []int{60},
+		},
+		{
+			name: "broken chain",
+			raw: []GarudaSegment{
+				seg("CGK", "2025-12-15T06:00:00+07:00", "SUB", "2025-12-15T07:30:00+07:00", 0),
+				seg("UPG", "2025-12-15T08:30:00+08:00", "DPS", "2025-12-15T10:30:00+08:00", 0),
+			},
+		},
+		{
+			name: "does not reach the destination",
+			raw:  []GarudaSegment{seg("CGK", "2025-12-15T06:00:00+07:00", "SUB", "2025-12-15T07:30:00+07:00", 0)},
+		},
+		{
+			name: "unreadable time",
+			raw:  []GarudaSegment{seg("CGK", "tomorrow", "DPS", "2025-12-15T08:50:00+08:00", 0)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			segments, layovers := garudaSegments(tt.raw, flight)
+			if got := route(segments); !equalStrings(got, tt.wantRoute) {
+				t.Fatalf("segments route = %v, want %v", got, tt.wantRoute)
+			}
+			if len(layovers) != len(tt.wantLayovers) {
+				t.Fatalf("got %d layovers, want %d", len(layovers), len(tt.wantLayovers))
+			}
+			for i, l := range layovers {
+				if l.DurationMin != tt.wantLayovers[i] {
+					t.Fatalf("layover %d = %dm, want %dm", i, l.DurationMin, tt.wantLayovers[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRouteSegmentsUnknownStops(t *testing.T) {
+	dep := time.Date(2025, 12, 15, 6, 0, 0, 0, time.UTC)
+	f := domain.Flight{Origin: "CGK", Destination: "DPS", Stops: 1, DepartureTime: dep, ArrivalTime: dep.Add(3 * time.Hour)}
+
+	if got := routeSegments(f); got != nil {
+		t.Fatalf("routeSegments = %v, want none for a stop without a layover", route(got))
+	}
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"sync"
+
+	"bookcabin/internal/common"
+	"bookcabin/internal/domain"
+)
+
+// searchAirports expands the requested origin and destination to concrete
+// airports (city codes, nearby_km) and runs one regular cached search per
+// airport pair, merging the results.
+func (uc *SearchFlightsUseCase) searchAirports(
+	ctx context.Context,
+	req domain.SearchRequest,
+	onProvider func(domain.ProviderUpdate),
+) (domain.SearchResult, error) {
+
+	origins := common.ExpandAirport(req.Origin, req.NearbyKM)
+	destinations := common.ExpandAirport(req.Destination, req.NearbyKM)
+
+	var pairs []domain.SearchRequest
+	for _, o := range origins {
+		for _, d := range destinations {
+			if o == d {
+				continue
+			}
+			pair := req
+			pair.Origin, pair.Destination = o, d
+			pair.NearbyKM = 0
+			pairs = append(pairs, pair)
+		}
+	}
+	if len(pairs) == 0 {
+		pairs = []domain.SearchRequest{req}
+	}
+
+	if len(pairs) == 1 {
+		res, err := uc.execute(ctx, pairs[0], onProvider)
+		res.OriginAirports, res.DestinationAirports = origins, destinations
+		return res, err
+	}
+
+	if onProvider != nil {
+		// pairs run concurrently, keep the callback one provider at a time
+		var mu sync.Mutex
+		inner := onProvider
+		onProvider = func(u domain.ProviderUpdate) {
+			mu.Lock()
+			defer mu.Unlock()
+			inner(u)
+		}
+	}
+
+	var (
+		wg      sync.WaitGroup
+		results = make([]domain.SearchResult, len(pairs))
+		errs    = make([]error, len(pairs))
+	)
+
+	for i, pair := range pairs {
+		wg.Add(1)
+		go func(i int, pair domain.SearchRequest) {
+			defer wg.Done()
+			results[i], errs[i] = uc.execute(ctx, pair, onProvider)
+		}(i, pair)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return domain.SearchResult{}, err
+		}
+	}
+
+	res := uc.mergePairResults(results, req)
+	res.OriginAirports, res.DestinationAirports = origins, destinations
+	return res, nil
+}
+
+// mergePairResults combines the searches of several airport pairs. Flights
+// are re-sorted together, facets are merged and each provider gets one report
+// whose status is the first failure, if any, across the pairs.
+func (uc *SearchFlightsUseCase) mergePairResults(results []domain.SearchResult, req domain.SearchRequest) domain.SearchResult {
+	out := newFanOutResult(len(uc.Providers))
+
+	index := make(map[string]int, len(uc.Providers))
+	for i, p := range uc.Providers {
+		index[p.Name()] = i
+	}
+
+	var (
+		flights   []domain.Flight
+		facets    []*domain.Facets
+		cacheHit  = true
+		stale     bool
+		coalesced bool
+	)
+
+	for _, r := range results {
+		flights = append(flights, r.Flights...)
+		facets = append(facets, r.Facets)
+		cacheHit = cacheHit && r.CacheHit
+		stale = stale || r.Stale
+		coalesced = coalesced || r.Coalesced
+		out.hedged += r.HedgedRequests
+		out.hedgesWon += r.HedgesWon
+
+		for _, rep := range r.Providers {
+			if i, ok := index[rep.Name]; ok {
+				out.providers[i] = mergeReport(out.providers[i], rep)
+			}
+		}
+	}
+
+	if req.SortBy != "" {
+		common.SortFlights(flights, req.SortBy, req.PriceBasis)
+	}
+
+	res := out.searchResult(flights, cacheHit)
+	res.Stale = stale
+	res.Coalesced = coalesced
+	res.Facets = mergeFacets(facets)
+	return res
+}
+
+func mergeReport(acc, rep domain.ProviderReport) domain.ProviderReport {
+	if acc.Status == "" {
+		return rep
+	}
+
+	if acc.Status == domain.ProviderStatusOK && rep.Status != domain.ProviderStatusOK {
+		acc.Status, acc.Error = rep.Status, rep.Error
+	}
+	acc.Cached = acc.Cached && rep.Cached
+	acc.LatencyMS = max(acc.LatencyMS, rep.LatencyMS)
+	acc.Attempts += rep.Attempts
+	acc.Hedged = acc.Hedged || rep.Hedged
+	acc.RawResults += rep.RawResults
+	acc.DroppedInvalid += rep.DroppedInvalid
+	acc.DroppedNormalization += rep.DroppedNormalization
+	acc.UnparsedBaggage += rep.UnparsedBaggage
+	return acc
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"bookcabin/internal/domain"
+)
+
+// ExecuteCalendar searches every day of month (YYYY-MM) on the request's
+// route, reusing cached days, and reports the lowest fare, its carrier and
+// direct-flight availability per day.
+func (uc *SearchFlightsUseCase) ExecuteCalendar(
+	ctx context.Context,
+	req domain.SearchRequest,
+	month string,
+) ([]domain.CalendarDay, error) {
+
+	first, err := time.Parse("2006-01", month)
+	if err != nil {
+		return nil, err
+	}
+
+	var dates []string
+	for d := first; d.Month() == first.Month(); d = d.AddDate(0, 0, 1) {
+		dates = append(dates, d.Format(dateLayout))
+	}
+
+	results, err := uc.searchDates(ctx, req, dates)
+	if err != nil {
+		return nil, err
+	}
+
+	days := make([]domain.CalendarDay, len(dates))
+	for i, res := range results {
+		days[i] = calendarDay(dates[i], res)
+	}
+	return days, nil
+}
+
+func calendarDay(date string, res domain.SearchResult) domain.CalendarDay {
+	day := domain.CalendarDay{Date: date}
+
+	for _, f := range res.Flights {
+		if !day.Available || f.PriceIDR < day.CheapestPriceIDR {
+			day.Available = true
+			day.CheapestPriceIDR = f.PriceIDR
+			day.Airline = f.Airline
+			day.AirlineCode = f.AirlineCode
+		}
+		if f.Stops == 0 && (!day.DirectAvailable || f.PriceIDR < day.CheapestDirectPriceIDR) {
+			day.DirectAvailable = true
+			day.CheapestDirectPriceIDR = f.PriceIDR
+		}
+	}
+
+	for _, p := range res.Providers {
+		if p.Status != domain.ProviderStatusOK {
+			day.Incomplete = true
+			day.FailedProviders = append(day.FailedProviders, p.Name)
+		}
+	}
+
+	return day
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"
+	BreakerOpen     BreakerState = "open"
+	BreakerHalfOpen BreakerState = "half_open"
+)
+
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures that opens the
+	// breaker. Zero disables the breaker.
+	FailureThreshold int
+	// CoolDown is how long the breaker stays open before letting a single
+	// half-open trial call through.
+	CoolDown time.Duration
+}
+
+// CircuitBreaker guards a single provider. It is closed while the provider
+// answers, opens after FailureThreshold consecutive failures and, once
+// CoolDown has passed, lets one trial call decide whether to close again.
+type CircuitBreaker struct {
+	cfg CircuitBreakerConfig
+	now func() time.Time
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	trial    bool
+	trialID  Permit
+}
+
+// Permit identifies an allowed call to Report. Only the half-open trial call
+// gets a non-zero permit.
+type Permit uint64
+
+func NewCircuitBreaker(cfg CircuitBreakerConfig) *CircuitBreaker {
+	return &CircuitBreaker{cfg: cfg, now: time.Now, state: BreakerClosed}
+}
+
+// Allow reports whether a call may go through. Every allowed call must be
+// followed by exactly one Report with the returned permit.
+func (b *CircuitBreaker) Allow() (Permit, bool) {
+	if b.cfg.FailureThreshold <= 0 {
+		return 0, true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Sub(b.openedAt) < b.cfg.CoolDown {
+			return 0, false
+		}
+		b.state = BreakerHalfOpen
+		return b.startTrial(), true
+	case BreakerHalfOpen:
+		// only one trial call at a time
+		if b.trial {
+			return 0, false
+		}
+		return b.startTrial(), true
+	default:
+		return 0, true
+	}
+}
+
+func (b *CircuitBreaker) startTrial() Permit {
+	b.trial = true
+	b.trialID++
+	return b.trialID
+}
+
+// Report records the outcome of an allowed call. A call cancelled by the
+// caller says nothing about the provider and only frees the trial slot.
+// While half-open only the trial call's report counts; late answers from
+// calls made before the breaker opened are ignored.
+func (b *CircuitBreaker) Report(p Permit, err error) {
+	if b.cfg.FailureThreshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		// late answer from a call made before the breaker opened
+		return
+	case BreakerHalfOpen:
+		if p == 0 || p != b.trialID {
+			return
+		}
+		b.trial = false
+	}
+
+	switch {
+	case err == nil:
+		b.state = BreakerClosed
+		b.failures = 0
+	case errors.Is(err, context.Canceled):
+		// caller went away, not the provider's fault
+	case b.state == BreakerHalfOpen:
+		b.open()
+	default:
+		b.failures++
+		if b.failures >= b.cfg.FailureThreshold {
+			b.open()
+		}
+	}
+}
+
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+func (b *CircuitBreaker) open() {
+	b.state = BreakerOpen
+	b.openedAt = b.now()
+	b.failures = 0
+}
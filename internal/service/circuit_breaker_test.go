@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errProvider = errors.New("provider down")
+
+// breakerStep is one action against the breaker: advance the clock, ask
+// Allow, or Report the permit of an earlier allowed call.
+type breakerStep struct {
+	advance time.Duration
+	allow   string // name to keep the permit under; "" to skip
+	want    bool   // expected Allow result
+	report  string // name of the permit to report; "" to skip
+	err     error
+	state   BreakerState // expected state after the step
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	cfg := CircuitBreakerConfig{FailureThreshold: 2, CoolDown: time.Minute}
+
+	tests := []struct {
+		name  string
+		steps []breakerStep
+	}{
+		{
+			name: "opens after consecutive failures",
+			steps: []breakerStep{
+				{allow: "a", want: true, state: BreakerClosed},
+				{report: "a", err: errProvider, state: BreakerClosed},
+				{allow: "b", want: true, state: BreakerClosed},
+				{report: "b", err: errProvider, state: BreakerOpen},
+				{allow: "c", want: false, state: BreakerOpen},
+			},
+		},
+		{
+			name: "success resets the failure count",
+			steps: []breakerStep{
+				{allow: "a", want: true},
+				{report: "a", err: errProvider, state: BreakerClosed},
+				{allow: "b", want: true},
+				{report: "b", state: BreakerClosed},
+				{allow: "c", want: true},
+				{report: "c", err: errProvider, state: BreakerClosed},
+			},
+		},
+		{
+			name: "half-open admits a single trial that closes on success",
+			steps: []breakerStep{
+				{allow: "a", want: true},
+				{report: "a", err: errProvider},
+				{allow: "b", want: true},
+				{report: "b", err: errProvider, state: BreakerOpen},
+				{advance: 30 * time.Second, allow: "early", want: false, state: BreakerOpen},
+				{advance: 31 * time.Second, allow: "trial", want: true, state: BreakerHalfOpen},
+				{allow: "second", want: false, state: BreakerHalfOpen},
+				{report: "trial", state: BreakerClosed},
+				{allow: "after", want: true, state: BreakerClosed},
+			},
+		},
+		{
+			name: "failed trial opens again",
+			steps: []breakerStep{
+				{allow: "a", want: true},
+				{report: "a", err: errProvider},
+				{allow: "b", want: true},
+				{report: "b", err: errProvider, state: BreakerOpen},
+				{advance: time.Minute, allow: "trial", want: true, state: BreakerHalfOpen},
+				{report: "trial", err: errProvider, state: BreakerOpen},
+				{allow: "c", want: false, state: BreakerOpen},
+			},
+		},
+		{
+			name: "cancelled trial only frees the slot",
+			steps: []breakerStep{
+				{allow: "a", want: true},
+				{report: "a", err: errProvider},
+				{allow: "b", want: true},
+				{report: "b", err: errProvider, state: BreakerOpen},
+				{advance: time.Minute, allow: "trial", want: true, state: BreakerHalfOpen},
+				{report: "trial", err: context.Canceled, state: BreakerHalfOpen},
+				{allow: "trial2", want: true, state: BreakerHalfOpen},
+			},
+		},
+		{
+			name: "late report from before opening does not end the trial",
+			steps: []breakerStep{
+				{allow: "slow", want: true},
+				{allow: "a", want: true},
+				{report: "a", err: errProvider},
+				{allow: "b", want: true},
+				{report: "b", err: errProvider, state: BreakerOpen},
+				{advance: time.Minute, allow: "trial", want: true, state: BreakerHalfOpen},
+				{report: "slow", state: BreakerHalfOpen},
+				{allow: "second", want: false, state: BreakerHalfOpen},
+				{report: "trial", err: errProvider, state: BreakerOpen},
+			},
+		},
+		{
+			name: "late failure while open is ignored",
+			steps: []breakerStep{
+				{allow: "slow", want: true},
+				{allow: "a", want: true},
+				{report: "a", err: errProvider},
+				{allow: "b", want: true},
+				{report: "b", err: errProvider, state: BreakerOpen},
+				{advance: 59 * time.Second, report: "slow", err: errProvider, state: BreakerOpen},
+				{advance: time.Second, allow: "trial", want: true, state: BreakerHalfOpen},
+			},
+		},
+		{
+			name: "late success while open is ignored",
+			steps: []breakerStep{
+				{allow: "slow", want: true},
+				{allow: "a", want: true},
+				{report: "a", err: errProvider},
+				{allow: "b", want: true},
+				{report: "b", err: errProvider, state: BreakerOpen},
+				{advance: 30 * time.Second, report: "slow", state: BreakerOpen},
+				{allow: "early", want: false, state: BreakerOpen},
+				{advance: 30 * time.Second, allow: "trial", want: true, state: BreakerHalfOpen},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC)
+			b := NewCircuitBreaker(cfg)
+			b.now = func() time.Time { return now }
+			permits := map[string]Permit{}
+
+			for i, s := range tt.steps {
+				now = now.Add(s.advance)
+
+				if s.allow != "" {
+					p, ok := b.Allow()
+					if ok != s.want {
+						t.Fatalf("step %d: Allow() = %t, want %t", i, ok, s.want)
+					}
+					permits[s.allow] = p
+				}
+				if s.report != "" {
+					b.Report(permits[s.report], s.err)
+				}
+				if s.state != "" && b.State() != s.state {
+					t.Fatalf("step %d: state = %s, want %s", i, b.State(), s.state)
+				}
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	b := NewCircuitBreaker(CircuitBreakerConfig{})
+	for i := 0; i < 10; i++ {
+		p, ok := b.Allow()
+		if !ok {
+			t.Fatalf("call %d rejected by a disabled breaker", i)
+		}
+		b.Report(p, errProvider)
+	}
+	if b.State() != BreakerClosed {
+		t.Fatalf("state = %s, want %s", b.State(), BreakerClosed)
+	}
+}
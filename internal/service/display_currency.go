@@ -0,0 +1,46 @@
+package service
+
+import (
+	"bookcabin/internal/common"
+	"bookcabin/internal/domain"
+)
+
+// displayFlights sets the display amount of every flight, its alternative
+// offers and self-transfer legs included, in currency. Nothing is done when
+// no currency was requested.
+func (uc *SearchFlightsUseCase) displayFlights(flights []domain.Flight, currency string) error {
+	if currency == "" {
+		return nil
+	}
+
+	for i := range flights {
+		amount, err := common.ConvertFromIDR(flights[i].PriceIDR, currency, uc.FX)
+		if err != nil {
+			return err
+		}
+		flights[i].DisplayAmount = amount
+		flights[i].DisplayCurrency = currency
+
+		for j := range flights[i].AlternativeOffers {
+			offer := &flights[i].AlternativeOffers[j]
+			if offer.DisplayAmount, err = common.ConvertFromIDR(offer.PriceIDR, currency, uc.FX); err != nil {
+				return err
+			}
+			offer.DisplayCurrency = currency
+		}
+
+		if err := uc.displayFlights(flights[i].Legs, currency); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// displayTotal converts a combined IDR total for display, or returns "" when
+// no currency was requested.
+func (uc *SearchFlightsUseCase) displayTotal(idr int64, currency string) (string, error) {
+	if currency == "" {
+		return "", nil
+	}
+	return common.ConvertFromIDR(idr, currency, uc.FX)
+}
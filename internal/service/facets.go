@@ -0,0 +1,158 @@
+package service
+
+import (
+	"sort"
+
+	"bookcabin/internal/common"
+	"bookcabin/internal/domain"
+)
+
+// priceBucketIDR is the width of a price histogram bucket. A fixed width keeps
+// histograms of separately searched airport pairs mergeable.
+const priceBucketIDR = 250_000
+
+// buildFacets summarises the bookable flights matching the request's route,
+// date, cabin and party size, ignoring every other filter.
+func (uc *SearchFlightsUseCase) buildFacets(flights []domain.Flight, req domain.SearchRequest) *domain.Facets {
+	base := domain.FlightFilter{
+		MaxStops:   -1,
+		Cabin:      common.NormalizeCabin(req.CabinClass),
+		Passengers: req.Passengers,
+	}
+
+	acc := newFacetAccumulator()
+	for _, f := range mergeDuplicates(filterFlights(flights, req, base), uc.Codeshares) {
+		acc.addFlight(f)
+	}
+	return acc.facets()
+}
+
+// mergeFacets combines the facets of several searches; nil entries are
+// ignored and nil is returned if all are nil.
+func mergeFacets(all []*domain.Facets) *domain.Facets {
+	acc := newFacetAccumulator()
+	merged := false
+	for _, fc := range all {
+		if fc != nil {
+			acc.addFacets(fc)
+			merged = true
+		}
+	}
+	if !merged {
+		return nil
+	}
+	return acc.facets()
+}
+
+type facetAccumulator struct {
+	out      domain.Facets
+	buckets  map[int64]int
+	airlines map[string]*domain.AirlineFacet
+	stops    map[int]*domain.StopsFacet
+	hours    map[int]int
+}
+
+func newFacetAccumulator() *facetAccumulator {
+	return &facetAccumulator{
+		buckets:  make(map[int64]int),
+		airlines: make(map[string]*domain.AirlineFacet),
+		stops:    make(map[int]*domain.StopsFacet),
+		hours:    make(map[int]int),
+	}
+}
+
+func (a *facetAccumulator) addFlight(f domain.Flight) {
+	a.addRanges(1, f.PriceIDR, f.PriceIDR, f.DurationMin, f.DurationMin)
+	a.buckets[f.PriceIDR/priceBucketIDR*priceBucketIDR]++
+	a.addAirline(domain.AirlineFacet{AirlineCode: f.AirlineCode, Airline: f.Airline, Count: 1, LowestPriceIDR: f.PriceIDR})
+	a.addStops(domain.StopsFacet{Stops: f.Stops, Count: 1, LowestPriceIDR: f.PriceIDR})
+	a.hours[f.DepartureTime.Hour()]++
+}
+
+func (a *facetAccumulator) addFacets(fc *domain.Facets) {
+	if fc.Count == 0 {
+		return
+	}
+	a.addRanges(fc.Count, fc.Price.MinIDR, fc.Price.MaxIDR, fc.Duration.MinMinutes, fc.Duration.MaxMinutes)
+	for _, b := range fc.Price.Histogram {
+		a.buckets[b.FromIDR] += b.Count
+	}
+	for _, af := range fc.Airlines {
+		a.addAirline(af)
+	}
+	for _, sf := range fc.Stops {
+		a.addStops(sf)
+	}
+	for _, h := range fc.DepartureHours {
+		a.hours[h.Hour] += h.Count
+	}
+}
+
+func (a *facetAccumulator) addRanges(count int, minPrice, maxPrice int64, minDur, maxDur int) {
+	if a.out.Count == 0 {
+		a.out.Price.MinIDR, a.out.Price.MaxIDR = minPrice, maxPrice
+		a.out.Duration.MinMinutes, a.out.Duration.MaxMinutes = minDur, maxDur
+	} else {
+		a.out.Price.MinIDR = min(a.out.Price.MinIDR, minPrice)
+		a.out.Price.MaxIDR = max(a.out.Price.MaxIDR, maxPrice)
+		a.out.Duration.MinMinutes = min(a.out.Duration.MinMinutes, minDur)
+		a.out.Duration.MaxMinutes = max(a.out.Duration.MaxMinutes, maxDur)
+	}
+	a.out.Count += count
+}
+
+func (a *facetAccumulator) addAirline(af domain.AirlineFacet) {
+	cur, ok := a.airlines[af.AirlineCode]
+	if !ok {
+		a.airlines[af.AirlineCode] = &af
+		return
+	}
+	cur.Count += af.Count
+	cur.LowestPriceIDR = min(cur.LowestPriceIDR, af.LowestPriceIDR)
+}
+
+func (a *facetAccumulator) addStops(sf domain.StopsFacet) {
+	cur, ok := a.stops[sf.Stops]
+	if !ok {
+		a.stops[sf.Stops] = &sf
+		return
+	}
+	cur.Count += sf.Count
+	cur.LowestPriceIDR = min(cur.LowestPriceIDR, sf.LowestPriceIDR)
+}
+
+// facets returns the accumulated facets with every list sorted.
+func (a *facetAccumulator) facets() *domain.Facets {
+	out := a.out
+	out.Price.Histogram = []domain.PriceBucket{}
+	out.Airlines = []domain.AirlineFacet{}
+	out.Stops = []domain.StopsFacet{}
+	out.DepartureHours = []domain.HourFacet{}
+
+	for from, n := range a.buckets {
+		out.Price.Histogram = append(out.Price.Histogram, domain.PriceBucket{FromIDR: from, ToIDR: from + priceBucketIDR, Count: n})
+	}
+	sort.Slice(out.Price.Histogram, func(i, j int) bool { return out.Price.Histogram[i].FromIDR < out.Price.Histogram[j].FromIDR })
+
+	for _, af := range a.airlines {
+		out.Airlines = append(out.Airlines, *af)
+	}
+	sort.Slice(out.Airlines, func(i, j int) bool {
+		if out.Airlines[i].LowestPriceIDR != out.Airlines[j].LowestPriceIDR {
+			return out.Airlines[i].LowestPriceIDR < out.Airlines[j].LowestPriceIDR
+		}
+		return out.Airlines[i].AirlineCode < out.Airlines[j].AirlineCode
+	})
+
+	for _, sf := range a.stops {
+		out.Stops = append(out.Stops, *sf)
+	}
+	sort.Slice(out.Stops, func(i, j int) bool { return out.Stops[i].Stops < out.Stops[j].Stops })
+
+	for h, n := range a.hours {
+		out.DepartureHours = append(out.DepartureHours, domain.HourFacet{Hour: h, Count: n})
+	}
+	sort.Slice(out.DepartureHours, func(i, j int) bool { return out.DepartureHours[i].Hour < out.DepartureHours[j].Hour })
+
+	return &out
+}
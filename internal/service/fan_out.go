@@ -0,0 +1,255 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"bookcabin/internal/domain"
+)
+
+// providerState is what the use case remembers about a provider between
+// searches.
+type providerState struct {
+	breaker *CircuitBreaker
+	latency *latencyTracker
+}
+
+type providerResult struct {
+	index    int
+	provider string
+	result   domain.ProviderResult
+	err      error
+	latency  time.Duration
+	hedged   bool
+	hedgeWon bool
+}
+
+// fanOutResult holds one report and the valid flights of every provider,
+// aligned with uc.Providers. Entries of providers that were not asked are
+// left empty.
+type fanOutResult struct {
+	providers []domain.ProviderReport
+	flights   [][]domain.Flight
+	hedged    int
+	hedgesWon int
+}
+
+func newFanOutResult(n int) fanOutResult {
+	return fanOutResult{
+		providers: make([]domain.ProviderReport, n),
+		flights:   make([][]domain.Flight, n),
+	}
+}
+
+// fanOut concurrently queries the providers at the given indexes whose
+// breaker is closed and collects whatever answers before the search deadline
+// into out. Providers that have not answered by then are reported as timed out.
+func (uc *SearchFlightsUseCase) fanOut(
+	ctx context.Context,
+	req domain.SearchRequest,
+	out *fanOutResult,
+	indexes []int,
+	onProvider func(domain.ProviderUpdate),
+) {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	start := time.Now()
+
+	// buffered so providers that answer after the deadline never block
+	results := make(chan providerResult, len(indexes))
+	pending := 0
+
+	for _, i := range indexes {
+		p := uc.Providers[i]
+		out.providers[i] = domain.ProviderReport{Name: p.Name()}
+		out.flights[i] = nil
+
+		st := uc.state(p.Name())
+		permit, ok := st.breaker.Allow()
+		if !ok {
+			out.providers[i].Status = domain.ProviderStatusCircuitOpen
+			log.Printf("[WARN] provider %s skipped: circuit open", p.Name())
+			continue
+		}
+
+		pending++
+		go func(i int, p FlightProvider) {
+			r := uc.callProvider(ctx, p, st, req)
+			r.index = i
+			st.breaker.Report(permit, r.err)
+			results <- r
+		}(i, p)
+	}
+
+collect:
+	for ; pending > 0; pending-- {
+		select {
+		case r := <-results:
+			valid := out.add(r)
+
+			if onProvider != nil {
+				flights, _ := uc.filterAndSort(valid, req)
+				onProvider(domain.ProviderUpdate{
+					Provider: r.provider,
+					Err:      r.err,
+					Result:   out.searchResult(flights, false),
+				})
+			}
+
+		case <-ctx.Done():
+			// only the search deadline makes a provider late; a caller that
+			// went away says nothing about it
+			status := domain.ProviderStatusCancelled
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				status = domain.ProviderStatusTimeout
+			}
+			for _, i := range indexes {
+				if out.providers[i].Status == "" {
+					out.providers[i].Status = status
+					out.providers[i].LatencyMS = int(time.Since(start).Milliseconds())
+				}
+			}
+			log.Printf("[WARN] %d provider(s) did not answer before deadline: %v", pending, ctx.Err())
+			break collect
+		}
+	}
+}
+
+// add records a provider's answer and returns its valid flights.
+func (o *fanOutResult) add(r providerResult) []domain.Flight {
+	rep := &o.providers[r.index]
+	rep.Status = providerStatus(r.err)
+	rep.LatencyMS = int(r.latency.Milliseconds())
+	rep.Attempts = r.result.Attempts
+	rep.Hedged = r.hedged
+	rep.RawResults = r.result.RawResults
+	rep.DroppedNormalization = r.result.DroppedNormalization
+	rep.UnparsedBaggage = r.result.UnparsedBaggage
+
+	if r.hedged {
+		o.hedged++
+		log.Printf("[INFO] provider %s hedged, hedge won: %t", r.provider, r.hedgeWon)
+	}
+	if r.hedgeWon {
+		o.hedgesWon++
+	}
+
+	if r.err != nil {
+		rep.Error = r.err.Error()
+		log.Printf("[WARN] provider %s failed (%s) after %d attempt(s): %v",
+			r.provider, rep.Status, rep.Attempts, r.err)
+		return nil
+	}
+
+	var valid []domain.Flight
+	for _, f := range r.result.Flights {
+		if !isValidFlight(f) {
+			rep.DroppedInvalid++
+			continue
+		}
+		f.Provider = r.provider
+		valid = append(valid, f)
+	}
+
+	o.flights[r.index] = valid
+	return valid
+}
+
+// copyFrom takes over the entries at the given indexes from another result.
+func (o *fanOutResult) copyFrom(src fanOutResult, indexes []int) {
+	for _, i := range indexes {
+		o.providers[i] = src.providers[i]
+		o.flights[i] = src.flights[i]
+	}
+	o.hedged += src.hedged
+	o.hedgesWon += src.hedgesWon
+}
+
+// allFlights returns every provider's flights. Duplicates are collapsed only
+// after filtering, so an offer the filters drop never hides one they keep.
+func (o fanOutResult) allFlights() []domain.Flight {
+	var all []domain.Flight
+	for _, flights := range o.flights {
+		all = append(all, flights...)
+	}
+	return all
+}
+
+// searchResult summarises the provider reports. Providers still pending are
+// counted as queried but left out of the report list.
+func (o fanOutResult) searchResult(flights []domain.Flight, cacheHit bool) domain.SearchResult {
+	res := domain.SearchResult{
+		Flights:          flights,
+		CacheHit:         cacheHit,
+		ProvidersQueried: len(o.providers),
+		HedgedRequests:   o.hedged,
+		HedgesWon:        o.hedgesWon,
+	}
+
+	for _, p := range o.providers {
+		switch p.Status {
+		case "":
+			continue
+		case domain.ProviderStatusOK:
+			res.ProvidersSucceeded++
+		case domain.ProviderStatusTimeout:
+			res.ProvidersTimedOut++
+		case domain.ProviderStatusCircuitOpen:
+			res.SkippedCircuitOpen++
+		case domain.ProviderStatusCancelled:
+			// neither failed nor late
+		default:
+			res.ProvidersFailed++
+		}
+		res.Providers = append(res.Providers, p)
+	}
+
+	return res
+}
+
+func providerStatus(err error) domain.ProviderStatus {
+	switch {
+	case err == nil:
+		return domain.ProviderStatusOK
+	case errors.Is(err, context.Canceled):
+		return domain.ProviderStatusCancelled
+	case isTimeout(err):
+		return domain.ProviderStatusTimeout
+	case errors.Is(err, domain.ErrProviderDecode):
+		return domain.ProviderStatusDecodeError
+	case errors.Is(err, domain.ErrProviderBusiness):
+		return domain.ProviderStatusBusinessFailure
+	default:
+		return domain.ProviderStatusHTTPError
+	}
+}
+
+// state returns the breaker and latency history of the named provider,
+// creating them on first use.
+func (uc *SearchFlightsUseCase) state(provider string) *providerState {
+	uc.statesMu.Lock()
+	defer uc.statesMu.Unlock()
+
+	if st, ok := uc.states[provider]; ok {
+		return st
+	}
+
+	cfg, ok := uc.CircuitBreakers[provider]
+	if !ok {
+		cfg = uc.CircuitBreaker
+	}
+
+	if uc.states == nil {
+		uc.states = make(map[string]*providerState)
+	}
+	st := &providerState{
+		breaker: NewCircuitBreaker(cfg),
+		latency: &latencyTracker{},
+	}
+	uc.states[provider] = st
+	return st
+}
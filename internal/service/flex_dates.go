@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"bookcabin/internal/domain"
+)
+
+// ExecuteFlexGrid searches every date within ±req.FlexDays of the departure
+// date (and of the return date for round trips) and reports the cheapest
+// fare per date. Round trips also get the cheapest total for every
+// outbound/return date pair. Each date is a regular cached search, so
+// neighbouring and repeated grids reuse each other's results.
+func (uc *SearchFlightsUseCase) ExecuteFlexGrid(
+	ctx context.Context,
+	req domain.SearchRequest,
+) (domain.FlexGrid, error) {
+
+	outDates, err := flexDates(req.DepartureDate, req.FlexDays)
+	if err != nil {
+		return domain.FlexGrid{}, err
+	}
+
+	outReq := req
+	if req.ReturnDate != "" {
+		outReq = outboundRequest(req)
+	}
+	outbound, err := uc.searchDates(ctx, outReq, outDates)
+	if err != nil {
+		return domain.FlexGrid{}, err
+	}
+
+	grid := domain.FlexGrid{Outbound: cheapestPerDate(outDates, outbound)}
+	if req.ReturnDate == "" {
+		return grid, nil
+	}
+
+	inDates, err := flexDates(req.ReturnDate, req.FlexDays)
+	if err != nil {
+		return domain.FlexGrid{}, err
+	}
+	inbound, err := uc.searchDates(ctx, inboundRequest(req), inDates)
+	if err != nil {
+		return domain.FlexGrid{}, err
+	}
+
+	grid.Inbound = cheapestPerDate(inDates, inbound)
+
+	for i, out := range outDates {
+		for j, in := range inDates {
+			// YYYY-MM-DD compares chronologically as a string
+			if in < out {
+				continue
+			}
+
+			cell := domain.DateMatrixCell{DepartureDate: out, ReturnDate: in}
+			for _, t := range pairRoundTrips(outbound[i].Flights, inbound[j].Flights, req) {
+				if !cell.Available || t.TotalPriceIDR < cell.CheapestTotalIDR {
+					cell.Available = true
+					cell.CheapestTotalIDR = t.TotalPriceIDR
+				}
+			}
+			grid.Matrix = append(grid.Matrix, cell)
+		}
+	}
+
+	return grid, nil
+}
+
+// searchDates runs req once per date, at most DayConcurrency at a time, and
+// returns each date's result aligned with dates.
+func (uc *SearchFlightsUseCase) searchDates(
+	ctx context.Context,
+	req domain.SearchRequest,
+	dates []string,
+) ([]domain.SearchResult, error) {
+
+	limit := uc.DayConcurrency
+	if limit <= 0 {
+		limit = 4
+	}
+
+	var (
+		wg      sync.WaitGroup
+		sem     = make(chan struct{}, limit)
+		results = make([]domain.SearchResult, len(dates))
+		errs    = make([]error, len(dates))
+	)
+
+	for i, date := range dates {
+		wg.Add(1)
+		go func(i int, date string) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
+
+			dayReq := req
+			dayReq.DepartureDate = date
+			dayReq.SkipFacets = true
+			results[i], errs[i] = uc.Execute(ctx, dayReq)
+		}(i, date)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// flexDates lists the dates from date-days to date+days.
+func flexDates(date string, days int) ([]string, error) {
+	d, err := time.Parse(dateLayout, date)
+	if err != nil {
+		return nil, err
+	}
+
+	dates := make([]string, 0, 2*days+1)
+	for i := -days; i <= days; i++ {
+		dates = append(dates, d.AddDate(0, 0, i).Format(dateLayout))
+	}
+	return dates, nil
+}
+
+func cheapestPerDate(dates []string, results []domain.SearchResult) []domain.DateFare {
+	fares := make([]domain.DateFare, len(dates))
+	for i, date := range dates {
+		fares[i].Date = date
+		for _, f := range results[i].Flights {
+			if !fares[i].Available || f.PriceIDR < fares[i].CheapestPriceIDR {
+				fares[i].Available = true
+				fares[i].CheapestPriceIDR = f.PriceIDR
+				fares[i].FlightCode = f.FlightCode
+				fares[i].AirlineCode = f.AirlineCode
+			}
+		}
+	}
+	return fares
+}
@@ -1,8 +1,11 @@
 package service
 
-import "bookcabin/internal/domain"
+import (
+	"bookcabin/internal/domain"
+	"context"
+)
 
 type FlightProvider interface {
-	Search(req domain.SearchRequest) ([]domain.Flight, error)
+	Search(ctx context.Context, req domain.SearchRequest) (domain.ProviderResult, error)
 	Name() string
 }
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"bookcabin/internal/domain"
+)
+
+const latencyWindow = 100
+
+type HedgeConfig struct {
+	// Providers enables hedging per provider name. Providers that bill per
+	// call should stay off.
+	Providers map[string]bool
+	// Percentile of observed latency after which a hedge is sent (default 0.9).
+	Percentile float64
+	// MinSamples is how many calls must be observed before the
+	// percentile is trusted (default 20).
+	MinSamples int
+	// MinDelay is a floor for the hedge delay so fast providers are not
+	// hedged on noise.
+	MinDelay time.Duration
+}
+
+func (c HedgeConfig) enabled(provider string) bool {
+	return c.Providers[provider]
+}
+
+func (c HedgeConfig) percentile() float64 {
+	if c.Percentile <= 0 || c.Percentile >= 1 {
+		return 0.9
+	}
+	return c.Percentile
+}
+
+func (c HedgeConfig) minSamples() int {
+	if c.MinSamples <= 0 {
+		return 20
+	}
+	return c.MinSamples
+}
+
+// latencyTracker keeps a sliding window of a provider's call latencies.
+type latencyTracker struct {
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+}
+
+func (t *latencyTracker) observe(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.samples) < latencyWindow {
+		t.samples = append(t.samples, d)
+		return
+	}
+	t.samples[t.next] = d
+	t.next = (t.next + 1) % latencyWindow
+}
+
+// percentile returns the p-th percentile latency, or false when fewer than
+// minSamples calls have been observed.
+func (t *latencyTracker) percentile(p float64, minSamples int) (time.Duration, bool) {
+	t.mu.Lock()
+	sorted := append([]time.Duration(nil), t.samples...)
+	t.mu.Unlock()
+
+	if len(sorted) == 0 || len(sorted) < minSamples {
+		return 0, false
+	}
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[int(p*float64(len(sorted)-1))], true
+}
+
+type hedgeAttempt struct {
+	result domain.ProviderResult
+	err    error
+	hedge  bool
+}
+
+// callProvider runs a provider search. When hedging is enabled for the
+// provider and it has not answered within its observed latency percentile, an
+// identical request is sent and whichever answers first wins; the other one is
+// cancelled.
+//
+// The latency sample is always the whole call, measured from the first
+// request, so a hedge win still counts the slow request it replaced and the
+// hedge delay cannot drift below what callers actually wait. A call that ends
+// with its context is sampled too, as a lower bound.
+func (uc *SearchFlightsUseCase) callProvider(
+	ctx context.Context,
+	p FlightProvider,
+	st *providerState,
+	req domain.SearchRequest,
+) providerResult {
+
+	out := providerResult{provider: p.Name()}
+	start := time.Now()
+
+	var (
+		delay time.Duration
+		hedge bool
+	)
+	if uc.Hedging.enabled(p.Name()) {
+		delay, hedge = st.latency.percentile(uc.Hedging.percentile(), uc.Hedging.minSamples())
+		if delay < uc.Hedging.MinDelay {
+			delay = uc.Hedging.MinDelay
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // cancels the losing request
+
+	attempts := make(chan hedgeAttempt, 2)
+	launch := func(isHedge bool) {
+		go func() {
+			res, err := p.Search(ctx, req)
+			attempts <- hedgeAttempt{result: res, err: err, hedge: isHedge}
+		}()
+	}
+
+	launch(false)
+	inflight := 1
+
+	var timer <-chan time.Time
+	if hedge {
+		t := time.NewTimer(delay)
+		defer t.Stop()
+		timer = t.C
+	}
+
+	for {
+		select {
+		case <-timer:
+			timer = nil
+			out.hedged = true
+			inflight++
+			launch(true)
+
+		case a := <-attempts:
+			inflight--
+			// a failed request only loses if its twin is still running
+			if a.err != nil && inflight > 0 {
+				continue
+			}
+			if a.err == nil || ctx.Err() != nil {
+				st.latency.observe(time.Since(start))
+			}
+			out.result = a.result
+			out.err = a.err
+			out.hedgeWon = a.hedge && a.err == nil
+			out.latency = time.Since(start)
+			return out
+		}
+	}
+}
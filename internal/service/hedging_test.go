@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"bookcabin/internal/domain"
+)
+
+// slowProvider answers a lone request after slow plus up to jitter, but a
+// request sent while another one is running (a hedge) after fast.
+type slowProvider struct {
+	slow, jitter, fast time.Duration
+	running            atomic.Int32
+	calls              atomic.Int32
+}
+
+func (p *slowProvider) Name() string { return "slow" }
+
+func (p *slowProvider) Search(ctx context.Context, _ domain.SearchRequest) (domain.ProviderResult, error) {
+	n := p.calls.Add(1)
+	d := p.slow
+	if p.jitter > 0 {
+		d += time.Duration(n*7%11) * p.jitter / 10
+	}
+	if p.running.Add(1) > 1 {
+		d = p.fast
+	}
+	defer p.running.Add(-1)
+
+	select {
+	case <-time.After(d):
+		return domain.ProviderResult{}, nil
+	case <-ctx.Done():
+		return domain.ProviderResult{}, ctx.Err()
+	}
+}
+
+func TestHedgeRateStaysStableForSlowProvider(t *testing.T) {
+	p := &slowProvider{slow: 15 * time.Millisecond, jitter: 10 * time.Millisecond, fast: 2 * time.Millisecond}
+	uc := &SearchFlightsUseCase{Hedging: HedgeConfig{
+		Providers:  map[string]bool{p.Name(): true},
+		MinSamples: 5,
+		MinDelay:   time.Millisecond,
+	}}
+	st := uc.state(p.Name())
+
+	const calls = 60
+	hedged := make([]bool, calls)
+	for i := range hedged {
+		r := uc.callProvider(context.Background(), p, st, domain.SearchRequest{})
+		if r.err != nil {
+			t.Fatalf("call %d: %v", i, r.err)
+		}
+		hedged[i] = r.hedged
+
+		// let the cancelled loser finish so the next call starts alone
+		for p.running.Load() > 0 {
+			time.Sleep(100 * time.Microsecond)
+		}
+	}
+
+	// a hedge win samples the whole call, so no sample is shorter than a
+	// caller ever waited and the delay cannot drift down
+	if d, ok := st.latency.percentile(0, 1); !ok || d < p.slow {
+		t.Fatalf("shortest sample = %s, want at least the slow latency %s", d, p.slow)
+	}
+
+	// hedging past p90 fires on roughly one call in ten; allow for noise
+	late := 0
+	for _, h := range hedged[calls/2:] {
+		if h {
+			late++
+		}
+	}
+	if late > calls/6 {
+		t.Fatalf("%d of the last %d calls were hedged, want at most %d", late, calls/2, calls/6)
+	}
+}
+
+func TestHedgeSamplesCallsEndedByContext(t *testing.T) {
+	p := &slowProvider{slow: time.Second, fast: time.Second}
+	uc := &SearchFlightsUseCase{}
+	st := uc.state(p.Name())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if r := uc.callProvider(ctx, p, st, domain.SearchRequest{}); r.err == nil {
+		t.Fatal("want the deadline error")
+	}
+	if d, ok := st.latency.percentile(0.9, 1); !ok || d < 20*time.Millisecond {
+		t.Fatalf("sample = %s (ok %t), want the elapsed time as a lower bound", d, ok)
+	}
+}
+
+func TestLatencyTrackerPercentile(t *testing.T) {
+	tests := []struct {
+		name       string
+		samples    int
+		minSamples int
+		p          float64
+		want       time.Duration
+		wantOK     bool
+	}{
+		{name: "too few samples", samples: 4, minSamples: 5, p: 0.9},
+		{name: "p90 of 1..10ms", samples: 10, minSamples: 5, p: 0.9, want: 9 * time.Millisecond, wantOK: true},
+		{name: "window keeps the last 100", samples: 150, minSamples: 5, p: 0, want: 51 * time.Millisecond, wantOK: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var lt latencyTracker
+			for i := 1; i <= tt.samples; i++ {
+				lt.observe(time.Duration(i) * time.Millisecond)
+			}
+			got, ok := lt.percentile(tt.p, tt.minSamples)
+			if ok != tt.wantOK || got != tt.want {
+				t.Fatalf("percentile = %s, %t; want %s, %t", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"bookcabin/internal/domain"
+)
+
+// mergeDuplicates collapses offers for the same operating flight coming from
+// different sources. Two flights are the same when they have the same
+// operating flight number, departure time and cabin. A flight's operating
+// number is its own unless codeshares maps its marketed number to another
+// carrier's; flights of different carriers on the same schedule are never
+// merged otherwise. The cheapest offer becomes the primary flight and the
+// others are attached as AlternativeOffers, cheapest first.
+func mergeDuplicates(flights []domain.Flight, codeshares map[string]string) []domain.Flight {
+	var (
+		groups [][]domain.Flight
+		byKey  = make(map[string]int)
+	)
+
+	for _, f := range flights {
+		key := operatingFlightKey(f, codeshares)
+
+		idx, ok := byKey[key]
+		if !ok {
+			idx = len(groups)
+			groups = append(groups, nil)
+			byKey[key] = idx
+		}
+		groups[idx] = append(groups[idx], f)
+	}
+
+	merged := make([]domain.Flight, 0, len(groups))
+	for _, g := range groups {
+		if len(g) == 1 {
+			merged = append(merged, g[0])
+			continue
+		}
+
+		sort.SliceStable(g, func(i, j int) bool { return g[i].PriceIDR < g[j].PriceIDR })
+
+		primary := g[0]
+		for _, alt := range g[1:] {
+			primary.AlternativeOffers = append(primary.AlternativeOffers, domain.Offer{
+				Provider:       alt.Provider,
+				FlightCode:     alt.FlightCode,
+				AirlineCode:    alt.AirlineCode,
+				PriceIDR:       alt.PriceIDR,
+				AvailableSeats: alt.AvailableSeats,
+			})
+		}
+		merged = append(merged, primary)
+	}
+
+	return merged
+}
+
+// flightNumber normalizes a flight code to carrier plus number, e.g.
+// "GA-400" or "400" from carrier GA both become "GA400".
+func flightNumber(f domain.Flight) string {
+	code := strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(f.FlightCode))
+	if f.AirlineCode != "" && !strings.HasPrefix(code, strings.ToUpper(f.AirlineCode)) {
+		code = strings.ToUpper(f.AirlineCode) + code
+	}
+	return code
+}
+
+func operatingFlightKey(f domain.Flight, codeshares map[string]string) string {
+	code := flightNumber(f)
+	if operating, ok := codeshares[code]; ok {
+		code = strings.ToUpper(operating)
+	}
+	return code + "|" + f.DepartureTime.UTC().Format(time.RFC3339) + "|" + f.Cabin
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"bookcabin/internal/domain"
+)
+
+func TestFilterAndSortMergesAfterFiltering(t *testing.T) {
+	dep := time.Date(2025, 12, 15, 8, 0, 0, 0, time.UTC)
+	offer := func(provider string, price int64, seats int) domain.Flight {
+		return domain.Flight{
+			Provider:       provider,
+			AirlineCode:    "GA",
+			FlightCode:     "GA400",
+			Origin:         "CGK",
+			Destination:    "DPS",
+			DepartureTime:  dep,
+			ArrivalTime:    dep.Add(2 * time.Hour),
+			DurationMin:    120,
+			Cabin:          "economy",
+			PriceIDR:       price,
+			AvailableSeats: seats,
+		}
+	}
+
+	flights := []domain.Flight{
+		offer("cheap", 900_000, 1),
+		offer("mid", 1_000_000, 4),
+		offer("dear", 1_200_000, 9),
+	}
+	req := domain.SearchRequest{
+		Origin:        "CGK",
+		Destination:   "DPS",
+		DepartureDate: "2025-12-15",
+		Passengers:    2,
+		MaxStops:      -1,
+	}
+
+	got, err := (&SearchFlightsUseCase{}).filterAndSort(flights, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the cheapest offer has too few seats; the flight must still be shown
+	// through the next offer that fits the party
+	if len(got) != 1 {
+		t.Fatalf("got %d flights, want the merged flight", len(got))
+	}
+	if got[0].Provider != "mid" {
+		t.Fatalf("primary offer from %q, want the cheapest qualifying one", got[0].Provider)
+	}
+	if alts := got[0].AlternativeOffers; len(alts) != 1 || alts[0].Provider != "dear" {
+		t.Fatalf("alternative offers = %+v, want only the other qualifying offer", alts)
+	}
+}
+
+// fixedRates serves the same rates for every lookup.
+type fixedRates map[string]string
+
+func (r fixedRates) Rate(currency string) (string, error) {
+	rate, ok := r[currency]
+	if !ok {
+		return "", errors.New("no rate for " + currency)
+	}
+	return rate, nil
+}
+
+func TestMergeDuplicates(t *testing.T) {
+	dep := time.Date(2025, 12, 15, 8, 0, 0, 0, time.UTC)
+	flight := func(provider, airline, code string, price int64) domain.Flight {
+		return domain.Flight{
+			Provider:      provider,
+			AirlineCode:   airline,
+			FlightCode:    code,
+			Origin:        "CGK",
+			Destination:   "DPS",
+			DepartureTime: dep,
+			ArrivalTime:   dep.Add(2 * time.Hour),
+			Cabin:         "economy",
+			PriceIDR:      price,
+		}
+	}
+
+	tests := []struct {
+		name       string
+		flights    []domain.Flight
+		codeshares map[string]string
+		want       int
+	}{
+		{
+			name:    "same flight number from two sources",
+			flights: []domain.Flight{flight("a", "GA", "GA400", 900_000), flight("b", "GA", "GA-400", 800_000)},
+			want:    1,
+		},
+		{
+			name:    "number without carrier prefix",
+			flights: []domain.Flight{flight("a", "GA", "GA400", 900_000), flight("b", "GA", "400", 800_000)},
+			want:    1,
+		},
+		{
+			name:    "different carriers on the same schedule",
+			flights: []domain.Flight{flight("a", "JT", "JT740", 900_000), flight("b", "ID", "ID6740", 800_000)},
+			want:    2,
+		},
+		{
+			name:       "mapped codeshare",
+			flights:    []domain.Flight{flight("a", "JT", "JT740", 900_000), flight("b", "ID", "ID6740", 800_000)},
+			codeshares: map[string]string{"ID6740": "JT740"},
+			want:       1,
+		},
+		{
+			name: "different cabins",
+			flights: func() []domain.Flight {
+				business := flight("b", "GA", "GA400", 4_000_000)
+				business.Cabin = "business"
+				return []domain.Flight{flight("a", "GA", "GA400", 900_000), business}
+			}(),
+			want: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mergeDuplicates(tt.flights, tt.codeshares)
+			if len(got) != tt.want {
+				t.Fatalf("got %d flights, want %d", len(got), tt.want)
+			}
+			if tt.want == 1 {
+				if got[0].Provider != "b" || len(got[0].AlternativeOffers) != 1 || got[0].AlternativeOffers[0].Provider != "a" {
+					t.Fatalf("primary %q with offers %+v, want the cheapest as primary", got[0].Provider, got[0].AlternativeOffers)
+				}
+			}
+		})
+	}
+}
+
+func TestDisplayFlightsConvertsAlternativeOffers(t *testing.T) {
+	uc := &SearchFlightsUseCase{FX: fixedRates{"SGD": "12000"}}
+	flights := []domain.Flight{{
+		PriceIDR:          1_200_000,
+		AlternativeOffers: []domain.Offer{{Provider: "b", PriceIDR: 1_206_000}},
+	}}
+
+	if err := uc.displayFlights(flights, "SGD"); err != nil {
+		t.Fatal(err)
+	}
+	if f := flights[0]; f.DisplayAmount != "100.00" {
+		t.Fatalf("flight DisplayAmount = %q, want 100.00", f.DisplayAmount)
+	}
+	if o := flights[0].AlternativeOffers[0]; o.DisplayAmount != "100.50" || o.DisplayCurrency != "SGD" {
+		t.Fatalf("offer display = %q %q, want 100.50 SGD", o.DisplayAmount, o.DisplayCurrency)
+	}
+}
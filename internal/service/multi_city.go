@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"bookcabin/internal/common"
+	"bookcabin/internal/domain"
+)
+
+const (
+	// maxItineraries caps how many itineraries are returned.
+	maxItineraries = 200
+	// itineraryBeam is how many partial itineraries are kept after each leg,
+	// so the search stays bounded however many flights each leg has.
+	itineraryBeam = 1000
+)
+
+// ExecuteMultiCity searches every leg in parallel, each through the regular
+// cached fan-out, and combines one flight per leg into ranked itineraries.
+// Leg filters apply per leg; the price filters apply to the itinerary total.
+func (uc *SearchFlightsUseCase) ExecuteMultiCity(
+	ctx context.Context,
+	req domain.MultiCityRequest,
+) (domain.MultiCityResult, error) {
+
+	var (
+		wg      sync.WaitGroup
+		results = make([]domain.SearchResult, len(req.Legs))
+		errs    = make([]error, len(req.Legs))
+	)
+
+	for i, leg := range req.Legs {
+		wg.Add(1)
+		go func(i int, leg domain.Leg) {
+			defer wg.Done()
+			results[i], errs[i] = uc.Execute(ctx, legRequest(req.Search, leg))
+		}(i, leg)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return domain.MultiCityResult{}, err
+		}
+	}
+
+	gap := uc.MultiCityMinGap
+	if req.MinGap >= 0 {
+		gap = time.Duration(req.MinGap) * time.Minute
+	}
+
+	legs := make([][]domain.Flight, len(results))
+	for i, r := range results {
+		legs[i] = r.Flights
+	}
+
+	its := buildItineraries(legs, gap, req.Search)
+	if len(its) > maxItineraries {
+		its = its[:maxItineraries]
+	}
+
+	for i := range its {
+		total, err := uc.displayTotal(its[i].TotalPriceIDR, req.Search.Currency)
+		if err != nil {
+			return domain.MultiCityResult{}, err
+		}
+		its[i].TotalDisplayAmount = total
+	}
+
+	return domain.MultiCityResult{
+		Legs:        results,
+		Itineraries: its,
+	}, nil
+}
+
+// legRequest builds a single leg search from the shared request.
+func legRequest(shared domain.SearchRequest, leg domain.Leg) domain.SearchRequest {
+	req := shared
+	req.Origin = leg.Origin
+	req.Destination = leg.Destination
+	req.DepartureDate = leg.DepartureDate
+	req.ReturnDate = ""
+	req.MinPrice, req.MaxPrice = 0, 0
+	req.SkipFacets = true
+	return req
+}
+
+// buildItineraries extends partial itineraries one leg at a time with every
+// flight departing at least gap after the previous leg lands, keeping the
+// best itineraryBeam partials between legs. The result is ranked.
+func buildItineraries(legs [][]domain.Flight, gap time.Duration, req domain.SearchRequest) []domain.Itinerary {
+	partial := []domain.Itinerary{{}}
+
+	for _, flights := range legs {
+		var next []domain.Itinerary
+		for _, it := range partial {
+			for _, f := range flights {
+				if n := len(it.Flights); n > 0 && f.DepartureTime.Before(it.Flights[n-1].ArrivalTime.Add(gap)) {
+					continue
+				}
+				price := basisPrice(it.TotalPriceIDR+f.PriceIDR, it.PartyTotalIDR+f.Fare.GrandTotalIDR, req.PriceBasis)
+				if req.MaxPrice > 0 && price > req.MaxPrice {
+					continue
+				}
+				next = append(next, extendItinerary(it, f))
+			}
+		}
+
+		common.SortItineraries(next, req.SortBy)
+		if len(next) > itineraryBeam {
+			next = next[:itineraryBeam]
+		}
+		partial = next
+	}
+
+	its := partial[:0]
+	for _, it := range partial {
+		if req.MinPrice > 0 && basisPrice(it.TotalPriceIDR, it.PartyTotalIDR, req.PriceBasis) < req.MinPrice {
+			continue
+		}
+		its = append(its, it)
+	}
+	return its
+}
+
+func extendItinerary(it domain.Itinerary, f domain.Flight) domain.Itinerary {
+	flights := make([]domain.Flight, len(it.Flights), len(it.Flights)+1)
+	copy(flights, it.Flights)
+
+	return domain.Itinerary{
+		Flights:          append(flights, f),
+		TotalPriceIDR:    it.TotalPriceIDR + f.PriceIDR,
+		PartyTotalIDR:    it.PartyTotalIDR + f.Fare.GrandTotalIDR,
+		TotalDurationMin: it.TotalDurationMin + f.DurationMin,
+		MixedCarrier:     len(it.Flights) > 0 && (it.MixedCarrier || it.Flights[0].AirlineCode != f.AirlineCode),
+	}
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"bookcabin/internal/domain"
+)
+
+// searchSnapshot is the full result set a paginated search pages through.
+type searchSnapshot struct {
+	result domain.SearchResult
+	at     time.Time
+}
+
+// pageCursor is the decoded form of an opaque cursor: the snapshot, the
+// position in it and a fingerprint of the search it belongs to.
+type pageCursor struct {
+	Snapshot string `json:"s"`
+	Offset   int    `json:"o"`
+	Search   string `json:"q"`
+}
+
+// ExecutePage returns one page of req.PageSize flights. Without a cursor the
+// search runs and its filtered, sorted result is kept as a snapshot; with a
+// cursor the page is cut from that snapshot without contacting providers, so
+// pages stay consistent even if the provider cache refreshes in between.
+func (uc *SearchFlightsUseCase) ExecutePage(
+	ctx context.Context,
+	req domain.SearchRequest,
+) (domain.SearchResult, domain.PageInfo, error) {
+
+	var (
+		snap   searchSnapshot
+		id     string
+		offset int
+	)
+
+	if req.Cursor == "" {
+		res, err := uc.Execute(ctx, req)
+		if err != nil {
+			return domain.SearchResult{}, domain.PageInfo{}, err
+		}
+
+		snap = searchSnapshot{result: res, at: time.Now()}
+		if id, err = newSnapshotID(); err != nil {
+			return domain.SearchResult{}, domain.PageInfo{}, err
+		}
+		uc.Cache.Set(snapshotKey(id), snap, uc.snapshotTTL())
+	} else {
+		c, err := decodeCursor(req.Cursor)
+		if err != nil || c.Search != searchFingerprint(req) || c.Offset < 0 {
+			return domain.SearchResult{}, domain.PageInfo{}, domain.ErrInvalidCursor
+		}
+
+		v, ok := uc.Cache.Get(snapshotKey(c.Snapshot))
+		if snap, ok = v.(searchSnapshot); !ok {
+			return domain.SearchResult{}, domain.PageInfo{}, domain.ErrCursorExpired
+		}
+		id, offset = c.Snapshot, c.Offset
+	}
+
+	all := snap.result.Flights
+	offset = min(offset, len(all))
+	end := min(offset+req.PageSize, len(all))
+
+	page := snap.result
+	page.Flights = all[offset:end]
+
+	info := domain.PageInfo{
+		PageSize:     req.PageSize,
+		TotalResults: len(all),
+		SnapshotAt:   snap.at,
+	}
+	if end < len(all) {
+		info.NextCursor = encodeCursor(pageCursor{
+			Snapshot: id,
+			Offset:   end,
+			Search:   searchFingerprint(req),
+		})
+	}
+
+	return page, info, nil
+}
+
+func (uc *SearchFlightsUseCase) snapshotTTL() time.Duration {
+	if uc.SnapshotTTL <= 0 {
+		return 10 * time.Minute
+	}
+	return uc.SnapshotTTL
+}
+
+func snapshotKey(id string) string {
+	return "snapshot|" + id
+}
+
+func newSnapshotID() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// searchFingerprint identifies the search a cursor was issued for, so a
+// cursor cannot be replayed against different filters or sorting. The page
+// size may change between pages.
+func searchFingerprint(req domain.SearchRequest) string {
+	req.Cursor, req.PageSize = "", 0
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%+v", req)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func encodeCursor(c pageCursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (pageCursor, error) {
+	var c pageCursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(b, &c)
+	return c, err
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"sync"
+
+	"bookcabin/internal/common"
+	"bookcabin/internal/domain"
+)
+
+// maxRoundTrips caps how many priced combinations are returned.
+const maxRoundTrips = 200
+
+// ExecuteRoundTrip searches the outbound and inbound legs in parallel and
+// pairs them into priced round trips, mixed carriers included. Leg filters
+// are applied per leg; the price filters apply to the round-trip total.
+func (uc *SearchFlightsUseCase) ExecuteRoundTrip(
+	ctx context.Context,
+	req domain.SearchRequest,
+) (domain.RoundTripResult, error) {
+
+	outReq := outboundRequest(req)
+	inReq := inboundRequest(req)
+
+	var (
+		wg            sync.WaitGroup
+		outRes, inRes domain.SearchResult
+		outErr, inErr error
+	)
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		outRes, outErr = uc.Execute(ctx, outReq)
+	}()
+	go func() {
+		defer wg.Done()
+		inRes, inErr = uc.Execute(ctx, inReq)
+	}()
+	wg.Wait()
+
+	if outErr != nil {
+		return domain.RoundTripResult{}, outErr
+	}
+	if inErr != nil {
+		return domain.RoundTripResult{}, inErr
+	}
+
+	trips := pairRoundTrips(outRes.Flights, inRes.Flights, req)
+	common.SortRoundTrips(trips, req.SortBy)
+	if len(trips) > maxRoundTrips {
+		trips = trips[:maxRoundTrips]
+	}
+
+	for i := range trips {
+		total, err := uc.displayTotal(trips[i].TotalPriceIDR, req.Currency)
+		if err != nil {
+			return domain.RoundTripResult{}, err
+		}
+		trips[i].TotalDisplayAmount = total
+	}
+
+	return domain.RoundTripResult{
+		Outbound:   outRes,
+		Inbound:    inRes,
+		RoundTrips: trips,
+	}, nil
+}
+
+// outboundRequest builds the outbound leg search; the price filters are left
+// to the round-trip total.
+func outboundRequest(req domain.SearchRequest) domain.SearchRequest {
+	out := req
+	out.ReturnDate = ""
+	out.MinPrice, out.MaxPrice = 0, 0
+	return out
+}
+
+// inboundRequest builds the return leg search: origin and destination are
+// swapped and the leg filters come from the Return* fields.
+func inboundRequest(req domain.SearchRequest) domain.SearchRequest {
+	in := req
+	in.Origin, in.Destination = req.Destination, req.Origin
+	in.DepartureDate = req.ReturnDate
+	in.ReturnDate = ""
+	in.MinPrice, in.MaxPrice = 0, 0
+	in.SkipFacets = true
+
+	in.MaxStops = req.ReturnMaxStops
+	in.EarliestDep = req.ReturnEarliestDep
+	in.LatestDep = req.ReturnLatestDep
+	in.EarliestArr = req.ReturnEarliestArr
+	in.LatestArr = req.ReturnLatestArr
+
+	return in
+}
+
+// pairRoundTrips combines every outbound flight with every inbound flight
+// that departs after the outbound one has landed.
+func pairRoundTrips(outbound, inbound []domain.Flight, req domain.SearchRequest) []domain.RoundTrip {
+	var trips []domain.RoundTrip
+
+	for _, o := range outbound {
+		for _, in := range inbound {
+			if !in.DepartureTime.After(o.ArrivalTime) {
+				continue
+			}
+
+			total := o.PriceIDR + in.PriceIDR
+			party := o.Fare.GrandTotalIDR + in.Fare.GrandTotalIDR
+			price := basisPrice(total, party, req.PriceBasis)
+			if req.MinPrice > 0 && price < req.MinPrice {
+				continue
+			}
+			if req.MaxPrice > 0 && price > req.MaxPrice {
+				continue
+			}
+
+			trips = append(trips, domain.RoundTrip{
+				Outbound:      o,
+				Inbound:       in,
+				TotalPriceIDR: total,
+				PartyTotalIDR: party,
+				MixedCarrier:  o.AirlineCode != in.AirlineCode,
+			})
+		}
+	}
+
+	return trips
+}
+
+// basisPrice picks the per-passenger or the party total of a combination of
+// flights for the price filters.
+func basisPrice(perPerson, party int64, basis domain.PriceBasis) int64 {
+	if basis == domain.PriceTotal {
+		return party
+	}
+	return perPerson
+}
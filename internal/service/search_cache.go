@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"bookcabin/internal/domain"
+)
+
+// providerEntry is a single provider's cached answer for a search key,
+// failures included, so cache hits report real provider outcomes.
+type providerEntry struct {
+	report  domain.ProviderReport
+	flights []domain.Flight
+}
+
+func providerCacheKey(cacheKey, provider string) string {
+	return cacheKey + "|" + provider
+}
+
+// lookup fills out from the cache and returns the indexes of providers that
+// have no usable entry and the indexes of those served from a stale entry.
+func (uc *SearchFlightsUseCase) lookup(cacheKey string, out *fanOutResult) (missing, stale []int) {
+	for i, p := range uc.Providers {
+		v, isStale, ok := uc.Cache.GetWithStale(providerCacheKey(cacheKey, p.Name()))
+		entry, isEntry := v.(providerEntry)
+		if !ok || !isEntry {
+			missing = append(missing, i)
+			continue
+		}
+
+		out.providers[i] = entry.report
+		out.providers[i].Cached = true
+		out.flights[i] = entry.flights
+
+		if isStale {
+			stale = append(stale, i)
+		}
+	}
+	return missing, stale
+}
+
+// store caches the answers of the providers at the given indexes. Successful
+// answers are kept for CacheTTL (stale until StaleTTL); failures only for
+// NegativeCacheTTL so they are retried soon. Skipped providers are never
+// cached, their breaker decides when to try again.
+func (uc *SearchFlightsUseCase) store(cacheKey string, out fanOutResult, indexes []int) {
+	ttl := uc.CacheTTL
+	if ttl <= 0 {
+		ttl = 3 * time.Minute
+	}
+
+	for _, i := range indexes {
+		report := out.providers[i]
+		key := providerCacheKey(cacheKey, report.Name)
+		entry := providerEntry{report: report, flights: out.flights[i]}
+
+		switch report.Status {
+		case domain.ProviderStatusOK:
+			uc.Cache.SetWithStale(key, entry, ttl, uc.StaleTTL)
+		case domain.ProviderStatusCircuitOpen, domain.ProviderStatusCancelled, "":
+			// not asked, abandoned or still pending
+		default:
+			if uc.NegativeCacheTTL > 0 {
+				uc.Cache.Set(key, entry, uc.NegativeCacheTTL)
+			}
+		}
+	}
+}
+
+// fetchShared fans out to the providers at the given indexes, sharing the
+// fan-out with any identical one already in flight, and caches the answers.
+func (uc *SearchFlightsUseCase) fetchShared(
+	ctx context.Context,
+	cacheKey string,
+	req domain.SearchRequest,
+	indexes []int,
+) (fanOutResult, bool, error) {
+
+	names := make([]string, len(indexes))
+	for j, i := range indexes {
+		names[j] = uc.Providers[i].Name()
+	}
+	flightKey := cacheKey + "|" + strings.Join(names, ",")
+
+	v, err, shared := uc.inflight.Do(ctx, flightKey, func(ctx context.Context) (interface{}, error) {
+		out := newFanOutResult(len(uc.Providers))
+		uc.fanOut(ctx, req, &out, indexes, nil)
+		if ctx.Err() != nil {
+			// every waiter left, the result is incomplete
+			return out, ctx.Err()
+		}
+		uc.store(cacheKey, out, indexes)
+		return out, nil
+	})
+	if err != nil {
+		return fanOutResult{}, false, err
+	}
+
+	return v.(fanOutResult), shared, nil
+}
+
+// refresh re-fetches stale provider entries in the background. At most one
+// refresh runs per provider entry.
+func (uc *SearchFlightsUseCase) refresh(cacheKey string, req domain.SearchRequest, stale []int) {
+	var indexes []int
+	for _, i := range stale {
+		key := providerCacheKey(cacheKey, uc.Providers[i].Name())
+		if _, running := uc.refreshing.LoadOrStore(key, struct{}{}); !running {
+			indexes = append(indexes, i)
+		}
+	}
+	if len(indexes) == 0 {
+		return
+	}
+
+	go func() {
+		defer func() {
+			for _, i := range indexes {
+				uc.refreshing.Delete(providerCacheKey(cacheKey, uc.Providers[i].Name()))
+			}
+		}()
+
+		if _, _, err := uc.fetchShared(context.Background(), cacheKey, req, indexes); err != nil {
+			log.Printf("[WARN] background refresh of %s failed: %v", cacheKey, err)
+		}
+	}()
+}
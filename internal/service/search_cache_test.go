@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"bookcabin/internal/domain"
+	"bookcabin/internal/infra"
+)
+
+// countingProvider counts its searches and answers with err, or with no
+// flights. While block is set every search waits for it to close first.
+type countingProvider struct {
+	calls atomic.Int32
+	err   error
+	block chan struct{}
+}
+
+func (p *countingProvider) Name() string { return "counting" }
+
+func (p *countingProvider) Search(ctx context.Context, _ domain.SearchRequest) (domain.ProviderResult, error) {
+	p.calls.Add(1)
+	if p.block != nil {
+		select {
+		case <-p.block:
+		case <-ctx.Done():
+			return domain.ProviderResult{}, ctx.Err()
+		}
+	}
+	return domain.ProviderResult{}, p.err
+}
+
+func cacheTestRequest() domain.SearchRequest {
+	return domain.SearchRequest{
+		Origin:        "CGK",
+		Destination:   "DPS",
+		DepartureDate: "2025-12-15",
+		Passengers:    1,
+		MaxStops:      -1,
+	}
+}
+
+// waitForCalls polls until p has been searched n times.
+func waitForCalls(t *testing.T, p *countingProvider, n int32) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for p.calls.Load() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("provider searched %d times, want %d", p.calls.Load(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestConcurrentStaleHitsRefreshOnce(t *testing.T) {
+	p := &countingProvider{}
+	uc := &SearchFlightsUseCase{
+		Providers: []FlightProvider{p},
+		Cache:     infra.NewCache(),
+		CacheTTL:  10 * time.Millisecond,
+		StaleTTL:  time.Minute,
+	}
+	req := cacheTestRequest()
+
+	if _, err := uc.Execute(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	// hold the refresh open so every hit below finds the entry stale
+	p.block = make(chan struct{})
+
+	const hits = 20
+	var wg sync.WaitGroup
+	for i := 0; i < hits; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := uc.Execute(context.Background(), req)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if !res.Stale {
+				t.Error("result not flagged as stale")
+			}
+		}()
+	}
+	wg.Wait()
+
+	waitForCalls(t, p, 2)
+	close(p.block)
+
+	// wait for the refresh to finish and release its entry
+	deadline := time.Now().Add(time.Second)
+	for refreshing(uc) {
+		if time.Now().After(deadline) {
+			t.Fatal("refresh never finished")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if n := p.calls.Load(); n != 2 {
+		t.Fatalf("provider searched %d times, want the first search plus one refresh", n)
+	}
+}
+
+func refreshing(uc *SearchFlightsUseCase) bool {
+	running := false
+	uc.refreshing.Range(func(_, _ interface{}) bool {
+		running = true
+		return false
+	})
+	return running
+}
+
+func TestFailedProviderNegativeCached(t *testing.T) {
+	tests := []struct {
+		name      string
+		ttl       time.Duration
+		wantCalls int32
+	}{
+		{name: "within NegativeCacheTTL", ttl: time.Minute, wantCalls: 1},
+		{name: "without NegativeCacheTTL", wantCalls: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &countingProvider{err: errProvider}
+			uc := &SearchFlightsUseCase{
+				Providers:        []FlightProvider{p},
+				Cache:            infra.NewCache(),
+				NegativeCacheTTL: tt.ttl,
+			}
+
+			for i := 0; i < 3; i++ {
+				res, err := uc.Execute(context.Background(), cacheTestRequest())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if res.ProvidersFailed != 1 {
+					t.Fatalf("search %d: providers_failed = %d, want the cached failure", i, res.ProvidersFailed)
+				}
+			}
+			if n := p.calls.Load(); n != tt.wantCalls {
+				t.Fatalf("provider searched %d times, want %d", n, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestNegativeCacheExpires(t *testing.T) {
+	p := &countingProvider{err: errProvider}
+	uc := &SearchFlightsUseCase{
+		Providers:        []FlightProvider{p},
+		Cache:            infra.NewCache(),
+		NegativeCacheTTL: 20 * time.Millisecond,
+	}
+
+	uc.Execute(context.Background(), cacheTestRequest())
+	uc.Execute(context.Background(), cacheTestRequest())
+	time.Sleep(30 * time.Millisecond)
+	uc.Execute(context.Background(), cacheTestRequest())
+
+	if n := p.calls.Load(); n != 2 {
+		t.Fatalf("provider searched %d times, want a retry only after NegativeCacheTTL", n)
+	}
+}
@@ -2,11 +2,12 @@ package service
 
 import (
 	"context"
+	"errors"
 	"log"
+	"net"
 	"strconv"
 	"strings"
 	"sync"
-	"sync/atomic"
 	"time"
 
 	"bookcabin/internal/common"
@@ -14,88 +15,182 @@ import (
 	"bookcabin/internal/infra"
 )
 
+// dateLayout is the YYYY-MM-DD format of request dates.
+const dateLayout = "2006-01-02"
+
 type SearchFlightsUseCase struct {
 	Providers []FlightProvider
 	Cache     *infra.Cache
+
+	// CircuitBreaker is the default breaker config for every provider;
+	// CircuitBreakers overrides it per provider name.
+	CircuitBreaker  CircuitBreakerConfig
+	CircuitBreakers map[string]CircuitBreakerConfig
+
+	Hedging HedgeConfig
+
+	// CacheTTL is how long search results are served as fresh (default 3m).
+	// Until StaleTTL has passed they are still served, flagged as stale,
+	// while a single background refresh runs.
+	CacheTTL time.Duration
+	StaleTTL time.Duration
+	// NegativeCacheTTL is how long a failed provider answer is cached. Zero
+	// retries failed providers on the next request.
+	NegativeCacheTTL time.Duration
+
+	// FX converts IDR prices to the display currency a search asks for.
+	FX common.RateLookup
+
+	// MultiCityMinGap is the default minimum time between a multi-city leg's
+	// arrival and the next leg's departure.
+	MultiCityMinGap time.Duration
+
+	// SnapshotTTL is how long the result set behind a paginated search is
+	// kept for its next pages (default 10m).
+	SnapshotTTL time.Duration
+
+	// Codeshares maps a marketed flight number to the operating flight it is
+	// sold on, e.g. "ID6740": "JT740", so offers for both are merged into one
+	// flight. Numbers are carrier code plus digits, without separators.
+	Codeshares map[string]string
+
+	// SelfTransfer configures connections built from separately ticketed
+	// legs, added when a search asks for them. No hubs disables them.
+	SelfTransfer SelfTransferConfig
+
+	// DayConcurrency bounds how many per-day searches a calendar or
+	// flexible-date search runs at once (default 4).
+	DayConcurrency int
+
+	statesMu sync.Mutex
+	states   map[string]*providerState
+
+	// inflight shares one provider fan-out between identical concurrent
+	// cache misses
+	inflight infra.Group
+	// refreshing holds the provider cache keys with a background refresh
+	// running
+	refreshing sync.Map
 }
 
 func (uc *SearchFlightsUseCase) Execute(
 	ctx context.Context,
 	req domain.SearchRequest,
 ) (domain.SearchResult, error) {
+	res, err := uc.searchAirports(ctx, req, nil)
+	if err != nil {
+		return res, err
+	}
 
-	cacheKey := searchCacheKey(req)
+	if req.SelfTransfer && len(uc.SelfTransfer.Hubs) > 0 {
+		if res, err = uc.addSelfTransfers(ctx, req, res, nil); err != nil {
+			return res, err
+		}
+	}
+
+	return res, uc.displayFlights(res.Flights, req.Currency)
+}
 
-	// CACHE HIT
-	if v, ok := uc.Cache.Get(cacheKey); ok {
-		if cached, ok := v.([]domain.Flight); ok {
-			flights, _ := uc.filterAndSort(cached, req)
-
-			return domain.SearchResult{
-				Flights:            flights,
-				CacheHit:           true,
-				ProvidersQueried:   len(uc.Providers),
-				ProvidersSucceeded: len(uc.Providers),
-				ProvidersFailed:    0,
-			}, nil
+// ExecuteStream behaves like Execute but calls onProvider as soon as each
+// provider answers, before the merged result is returned. onProvider is called
+// one provider at a time; when a city code or nearby_km expands the search to
+// several airport pairs, every pair reports its own providers. Self-transfer
+// connections follow in one last update once every provider has answered.
+func (uc *SearchFlightsUseCase) ExecuteStream(
+	ctx context.Context,
+	req domain.SearchRequest,
+	onProvider func(domain.ProviderUpdate),
+) (domain.SearchResult, error) {
+	if req.Currency != "" {
+		inner := onProvider
+		onProvider = func(u domain.ProviderUpdate) {
+			if err := uc.displayFlights(u.Result.Flights, req.Currency); err != nil {
+				log.Printf("[WARN] display currency %s: %v", req.Currency, err)
+			}
+			inner(u)
 		}
 	}
 
-	// CACHE MISS
-	var (
-		wg        sync.WaitGroup
-		result    = make(chan []domain.Flight, len(uc.Providers))
-		succeeded int32
-		failed    int32
-	)
+	res, err := uc.searchAirports(ctx, req, onProvider)
+	if err != nil {
+		return res, err
+	}
 
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
+	if req.SelfTransfer && len(uc.SelfTransfer.Hubs) > 0 {
+		if res, err = uc.addSelfTransfers(ctx, req, res, onProvider); err != nil {
+			return res, err
+		}
+	}
 
-	for _, p := range uc.Providers {
-		wg.Add(1)
-		go func(p FlightProvider) {
-			defer wg.Done()
+	return res, uc.displayFlights(res.Flights, req.Currency)
+}
 
-			flights, err := p.Search(req)
-			if err != nil {
-				atomic.AddInt32(&failed, 1)
-				log.Printf("[WARN] provider %s failed: %v", p.Name(), err)
-				return
-			}
+func (uc *SearchFlightsUseCase) execute(
+	ctx context.Context,
+	req domain.SearchRequest,
+	onProvider func(domain.ProviderUpdate),
+) (domain.SearchResult, error) {
 
-			atomic.AddInt32(&succeeded, 1)
-			result <- flights
-		}(p)
-	}
+	cacheKey := searchCacheKey(req)
+	out := newFanOutResult(len(uc.Providers))
 
-	go func() {
-		wg.Wait()
-		close(result)
-	}()
+	// CACHE HIT, per provider
+	missing, stale := uc.lookup(cacheKey, &out)
+	if len(stale) > 0 {
+		uc.refresh(cacheKey, req, stale)
+	}
 
-	var allFlights []domain.Flight
-	for flights := range result {
-		for _, f := range flights {
-			if !isValidFlight(f) {
+	if onProvider != nil {
+		// replay cached answers one by one so counters stay running totals
+		seen := newFanOutResult(len(uc.Providers))
+		for i, report := range out.providers {
+			if report.Status == "" {
 				continue
 			}
-			allFlights = append(allFlights, f)
+			seen.providers[i] = report
+
+			flights, _ := uc.filterAndSort(out.flights[i], req)
+			onProvider(domain.ProviderUpdate{
+				Provider: report.Name,
+				Result:   seen.searchResult(flights, true),
+			})
 		}
 	}
 
-	uc.Cache.Set(cacheKey, allFlights, 3*time.Minute)
+	// CACHE MISS for providers without a usable entry
+	var shared bool
+	if len(missing) > 0 {
+		if onProvider != nil {
+			// streaming callers need their own per-provider callbacks, so
+			// only plain searches are coalesced with identical in-flight ones
+			uc.fanOut(ctx, req, &out, missing, onProvider)
+			if ctx.Err() == nil {
+				uc.store(cacheKey, out, missing)
+			}
+		} else {
+			fetched, isShared, err := uc.fetchShared(ctx, cacheKey, req, missing)
+			if err != nil {
+				return domain.SearchResult{}, err
+			}
+			out.copyFrom(fetched, missing)
+			shared = isShared
+		}
+	}
+
+	all := out.allFlights()
+	flights, _ := uc.filterAndSort(all, req)
 
-	flights, _ := uc.filterAndSort(allFlights, req)
-	return domain.SearchResult{
-		Flights:            flights,
-		CacheHit:           false,
-		ProvidersQueried:   len(uc.Providers),
-		ProvidersSucceeded: int(succeeded),
-		ProvidersFailed:    int(failed),
-	}, nil
+	result := out.searchResult(flights, len(missing) == 0)
+	result.Stale = len(stale) > 0
+	result.Coalesced = shared
+	if !req.SkipFacets {
+		result.Facets = uc.buildFacets(all, req)
+	}
+	return result, nil
 }
 
+// filterAndSort keeps the flights matching req, collapses the duplicates
+// among them and sorts the rest.
 func (uc *SearchFlightsUseCase) filterAndSort(
 	flights []domain.Flight,
 	req domain.SearchRequest,
@@ -106,10 +201,10 @@ func (uc *SearchFlightsUseCase) filterAndSort(
 		return nil, err
 	}
 
-	filtered := filterFlights(flights, req, filter)
+	filtered := mergeDuplicates(filterFlights(flights, req, filter), uc.Codeshares)
 
 	if req.SortBy != "" {
-		common.SortFlights(filtered, req.SortBy)
+		common.SortFlights(filtered, req.SortBy, req.PriceBasis)
 	}
 
 	return filtered, nil
@@ -122,10 +217,19 @@ func buildFlightFilterFromRequest(req domain.SearchRequest) (domain.FlightFilter
 		MaxStops:    req.MaxStops,
 		Airlines:    common.NormalizeAirlines(req.Airlines),
 		MaxDuration: req.MaxDuration,
+		Cabin:       common.NormalizeCabin(req.CabinClass),
+		Passengers:  req.Passengers,
+		PriceBasis:  req.PriceBasis,
+
+		CheckedBagIncluded: req.CheckedBagIncluded,
+
+		MaxLayover:          req.MaxLayover,
+		MinConnection:       req.MinConnection,
+		ExcludedConnections: connectionAirports(req.ExcludeConnections),
 	}
 
 	// parse base date
-	baseDate, err := time.Parse("2006-01-02", req.DepartureDate)
+	baseDate, err := time.Parse(dateLayout, req.DepartureDate)
 	if err != nil {
 		return filter, err
 	}
@@ -187,7 +291,7 @@ func filterFlights(
 
 	var res []domain.Flight
 
-	reqDate, err := time.Parse("2006-01-02", req.DepartureDate)
+	reqDate, err := time.Parse(dateLayout, req.DepartureDate)
 	if err != nil {
 		return res
 	}
@@ -206,11 +310,23 @@ func filterFlights(
 			continue
 		}
 
-		// price
-		if filter.MinPrice > 0 && f.PriceIDR < filter.MinPrice {
+		// cabin and seats for the whole party
+		if filter.Cabin != "" && f.Cabin != filter.Cabin {
+			continue
+		}
+		if filter.Passengers > 0 && f.AvailableSeats < filter.Passengers {
 			continue
 		}
-		if filter.MaxPrice > 0 && f.PriceIDR > filter.MaxPrice {
+		if filter.CheckedBagIncluded && !f.Baggage.CheckedIncluded {
+			continue
+		}
+
+		// price, per passenger or for the whole party
+		price := common.FlightPrice(f, filter.PriceBasis)
+		if filter.MinPrice > 0 && price < filter.MinPrice {
+			continue
+		}
+		if filter.MaxPrice > 0 && price > filter.MaxPrice {
 			continue
 		}
 
@@ -229,6 +345,10 @@ func filterFlights(
 			continue
 		}
 
+		if !layoversAllowed(f, filter) {
+			continue
+		}
+
 		// departure window
 		if !filter.EarliestDeparture.IsZero() &&
 			f.DepartureTime.Before(filter.EarliestDeparture) {
@@ -257,6 +377,38 @@ func filterFlights(
 	return res
 }
 
+// connectionAirports upper-cases the excluded connection airports and
+// expands city codes to their airports.
+func connectionAirports(codes []string) []string {
+	var out []string
+	for _, c := range codes {
+		if c = strings.TrimSpace(c); c != "" {
+			out = append(out, common.ExpandAirport(c, 0)...)
+		}
+	}
+	return out
+}
+
+// layoversAllowed applies the layover filters. A layover whose duration the
+// provider did not give (0) only counts for the excluded airports.
+func layoversAllowed(f domain.Flight, filter domain.FlightFilter) bool {
+	for _, l := range f.Layovers {
+		if contains(filter.ExcludedConnections, strings.ToUpper(l.Airport)) {
+			return false
+		}
+		if l.DurationMin == 0 {
+			continue
+		}
+		if filter.MaxLayover > 0 && l.DurationMin > filter.MaxLayover {
+			return false
+		}
+		if filter.MinConnection > 0 && l.DurationMin < filter.MinConnection {
+			return false
+		}
+	}
+	return true
+}
+
 func contains(arr []string, s string) bool {
 	for _, a := range arr {
 		if a == s {
@@ -270,6 +422,16 @@ func isValidFlight(f domain.Flight) bool {
 	return !f.DepartureTime.IsZero() && !f.ArrivalTime.IsZero() && f.ArrivalTime.After(f.DepartureTime)
 }
 
+// isTimeout reports whether a provider error was caused by the search deadline
+// or a transport-level timeout rather than a real provider failure.
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
 func searchCacheKey(req domain.SearchRequest) string {
 	parts := []string{
 		req.Origin,
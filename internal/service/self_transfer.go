@@ -0,0 +1,350 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"bookcabin/internal/common"
+	"bookcabin/internal/domain"
+)
+
+// maxSelfTransfers caps how many self-transfer connections a search adds.
+const maxSelfTransfers = 50
+
+// selfTransferProvider names the stream update carrying the connections.
+const selfTransferProvider = "Self-transfer"
+
+// SelfTransferConfig controls self-transfer connections. Hubs are the
+// airports connections may be built through; the time between landing and
+// the next departure must be between MinConnection (default 90m) and
+// MaxLayover (default 6h).
+type SelfTransferConfig struct {
+	Hubs          []string
+	MinConnection time.Duration
+	MaxLayover    time.Duration
+}
+
+func (c SelfTransferConfig) withDefaults() SelfTransferConfig {
+	if c.MinConnection <= 0 {
+		c.MinConnection = 90 * time.Minute
+	}
+	if c.MaxLayover <= 0 {
+		c.MaxLayover = 6 * time.Hour
+	}
+	return c
+}
+
+// addSelfTransfers searches origin→hub and hub→destination for every
+// configured hub, each as a regular cached search, joins legs that respect
+// the connection limits and ranks them together with res.Flights. When
+// onProvider is set the connections are reported in one update of their own.
+//
+// Like the rest of the facets, the self-transfer facets ignore the request's
+// filters and the cap: they are built from a second join of the unfiltered
+// legs, which the provider cache already holds.
+func (uc *SearchFlightsUseCase) addSelfTransfers(
+	ctx context.Context,
+	req domain.SearchRequest,
+	res domain.SearchResult,
+	onProvider func(domain.ProviderUpdate),
+) (domain.SearchResult, error) {
+
+	cfg := uc.SelfTransfer.withDefaults()
+
+	nextDay, err := time.Parse(dateLayout, req.DepartureDate)
+	if err != nil {
+		return res, err
+	}
+	secondDates := []string{req.DepartureDate, nextDay.AddDate(0, 0, 1).Format(dateLayout)}
+
+	var hubs []string
+	for _, hub := range cfg.Hubs {
+		if !contains(common.ExpandAirport(req.Origin, req.NearbyKM), hub) &&
+			!contains(common.ExpandAirport(req.Destination, req.NearbyKM), hub) {
+			hubs = append(hubs, hub)
+		}
+	}
+
+	withFacets := res.Facets != nil
+	scope := facetScope(req)
+
+	var (
+		mu          sync.Mutex
+		wg          sync.WaitGroup
+		connections []domain.Flight
+		unfiltered  []domain.Flight
+		firstErr    error
+	)
+
+	for _, hub := range hubs {
+		wg.Add(1)
+		go func(hub string) {
+			defer wg.Done()
+
+			first, second, err := uc.searchViaHub(ctx, req, hub, secondDates)
+			var all []domain.Flight
+			if err == nil && withFacets {
+				var allFirst, allSecond []domain.Flight
+				allFirst, allSecond, err = uc.searchViaHub(ctx, scope, hub, secondDates)
+				all = joinSelfTransfers(allFirst, allSecond, scope, cfg)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			connections = append(connections, joinSelfTransfers(first, second, req, cfg)...)
+			unfiltered = append(unfiltered, all...)
+		}(hub)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return res, firstErr
+	}
+
+	common.SortFlights(connections, req.SortBy, req.PriceBasis)
+	if len(connections) > maxSelfTransfers {
+		connections = connections[:maxSelfTransfers]
+	}
+
+	res.Flights = append(res.Flights, connections...)
+	res.SelfTransfers = len(connections)
+	if req.SortBy != "" {
+		common.SortFlights(res.Flights, req.SortBy, req.PriceBasis)
+	}
+	if withFacets {
+		res.Facets = mergeFacets([]*domain.Facets{res.Facets, uc.buildFacets(unfiltered, req)})
+	}
+
+	if onProvider != nil {
+		update := res
+		update.Flights = connections
+		onProvider(domain.ProviderUpdate{Provider: selfTransferProvider, Result: update})
+	}
+	return res, nil
+}
+
+// facetScope strips req down to what facets are scoped to: route, date,
+// cabin and party size.
+func facetScope(req domain.SearchRequest) domain.SearchRequest {
+	return domain.SearchRequest{
+		Origin:        req.Origin,
+		Destination:   req.Destination,
+		DepartureDate: req.DepartureDate,
+		Passengers:    req.Passengers,
+		CabinClass:    req.CabinClass,
+		NearbyKM:      req.NearbyKM,
+		MaxStops:      -1,
+		SkipFacets:    true,
+	}
+}
+
+// searchViaHub runs the first leg on the departure date and the second leg
+// on the departure date and the day after, for overnight connections.
+func (uc *SearchFlightsUseCase) searchViaHub(
+	ctx context.Context,
+	req domain.SearchRequest,
+	hub string,
+	secondDates []string,
+) ([]domain.Flight, []domain.Flight, error) {
+
+	// per-leg requests keep the filters that apply to a single leg; stops,
+	// duration and price are checked on the joined connection
+	firstReq := req
+	firstReq.Destination = hub
+	firstReq.SelfTransfer = false
+	firstReq.SkipFacets = true
+	firstReq.MinPrice, firstReq.MaxPrice = 0, 0
+	firstReq.MaxStops, firstReq.MaxDuration = -1, 0
+	firstReq.EarliestArr, firstReq.LatestArr = "", ""
+
+	secondReq := req
+	secondReq.Origin = hub
+	secondReq.SelfTransfer = false
+	secondReq.SkipFacets = true
+	secondReq.MinPrice, secondReq.MaxPrice = 0, 0
+	secondReq.MaxStops, secondReq.MaxDuration = -1, 0
+	secondReq.EarliestDep, secondReq.LatestDep = "", ""
+	secondReq.EarliestArr, secondReq.LatestArr = "", ""
+
+	var (
+		wg        sync.WaitGroup
+		firstRes  domain.SearchResult
+		secondRes []domain.SearchResult
+		firstErr  error
+		secondErr error
+	)
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		firstRes, firstErr = uc.Execute(ctx, firstReq)
+	}()
+	go func() {
+		defer wg.Done()
+		secondRes, secondErr = uc.searchDates(ctx, secondReq, secondDates)
+	}()
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, nil, firstErr
+	}
+	if secondErr != nil {
+		return nil, nil, secondErr
+	}
+
+	var second []domain.Flight
+	for _, r := range secondRes {
+		second = append(second, r.Flights...)
+	}
+	return firstRes.Flights, second, nil
+}
+
+// joinSelfTransfers pairs every first leg with every second leg leaving the
+// same airport within the connection limits, applying the request's
+// connection-wide filters.
+func joinSelfTransfers(
+	first, second []domain.Flight,
+	req domain.SearchRequest,
+	cfg SelfTransferConfig,
+) []domain.Flight {
+
+	var out []domain.Flight
+
+	// the hub layover counts as well, so the joined flight is checked again
+	layovers := domain.FlightFilter{
+		MaxLayover:          req.MaxLayover,
+		MinConnection:       req.MinConnection,
+		ExcludedConnections: connectionAirports(req.ExcludeConnections),
+	}
+
+	for _, a := range first {
+		for _, b := range second {
+			if !strings.EqualFold(a.Destination, b.Origin) {
+				continue
+			}
+
+			layover := b.DepartureTime.Sub(a.ArrivalTime)
+			if layover < cfg.MinConnection || layover > cfg.MaxLayover {
+				continue
+			}
+
+			f := selfTransferFlight(a, b)
+
+			price := common.FlightPrice(f, req.PriceBasis)
+			if req.MinPrice > 0 && price < req.MinPrice {
+				continue
+			}
+			if req.MaxPrice > 0 && price > req.MaxPrice {
+				continue
+			}
+			if req.MaxStops >= 0 && f.Stops > req.MaxStops {
+				continue
+			}
+			if req.MaxDuration > 0 && f.DurationMin > req.MaxDuration {
+				continue
+			}
+			if !layoversAllowed(f, layovers) {
+				continue
+			}
+			if !arrivesInWindow(f, req) {
+				continue
+			}
+
+			out = append(out, f)
+		}
+	}
+
+	return out
+}
+
+// arrivesInWindow applies the request's arrival window, which the second
+// leg search leaves out because that leg may land on the next day.
+func arrivesInWindow(f domain.Flight, req domain.SearchRequest) bool {
+	if req.EarliestArr == "" && req.LatestArr == "" {
+		return true
+	}
+
+	filter, err := buildFlightFilterFromRequest(req)
+	if err != nil {
+		return false
+	}
+	if !filter.EarliestArrival.IsZero() && f.ArrivalTime.Before(filter.EarliestArrival) {
+		return false
+	}
+	if !filter.LatestArrival.IsZero() && f.ArrivalTime.After(filter.LatestArrival) {
+		return false
+	}
+	return true
+}
+
+// selfTransferFlight presents two separately ticketed legs as one flight so
+// it can be filtered and ranked like any other.
+func selfTransferFlight(a, b domain.Flight) domain.Flight {
+	f := domain.Flight{
+		FlightCode:     a.FlightCode + "+" + b.FlightCode,
+		Airline:        a.Airline,
+		AirlineCode:    a.AirlineCode,
+		Origin:         a.Origin,
+		Destination:    b.Destination,
+		DepartureTime:  a.DepartureTime,
+		ArrivalTime:    b.ArrivalTime,
+		DurationMin:    int(b.ArrivalTime.Sub(a.DepartureTime).Minutes()),
+		Stops:          a.Stops + b.Stops + 1,
+		PriceIDR:       a.PriceIDR + b.PriceIDR,
+		Fare:           common.AddFares(a.Fare, b.Fare),
+		AvailableSeats: min(a.AvailableSeats, b.AvailableSeats),
+		Cabin:          a.Cabin,
+		Aircraft:       a.Aircraft,
+		Provider:       a.Provider,
+		SelfTransfer:   true,
+		Legs:           []domain.Flight{a, b},
+	}
+
+	// the self-transfer itself is a layover at the hub; segments are only
+	// listed when both tickets list theirs
+	if len(a.Segments) > 0 && len(b.Segments) > 0 {
+		f.Segments = append(append(f.Segments, a.Segments...), b.Segments...)
+	}
+	f.Layovers = append(f.Layovers, a.Layovers...)
+	f.Layovers = append(f.Layovers, domain.Layover{
+		Airport:     a.Destination,
+		DurationMin: int(b.DepartureTime.Sub(a.ArrivalTime).Minutes()),
+	})
+	f.Layovers = append(f.Layovers, b.Layovers...)
+	f.FlyingMin, f.GroundMin = common.SplitTravelTime(f)
+
+	// bags are not checked through, so the smaller allowance of the two
+	// tickets is what can be carried the whole way
+	f.Baggage = domain.Baggage{
+		CabinPieces:     min(a.Baggage.CabinPieces, b.Baggage.CabinPieces),
+		CabinKg:         min(a.Baggage.CabinKg, b.Baggage.CabinKg),
+		CheckedPieces:   min(a.Baggage.CheckedPieces, b.Baggage.CheckedPieces),
+		CheckedKg:       min(a.Baggage.CheckedKg, b.Baggage.CheckedKg),
+		CheckedIncluded: a.Baggage.CheckedIncluded && b.Baggage.CheckedIncluded,
+		Parsed:          a.Baggage.Parsed && b.Baggage.Parsed,
+	}
+
+	if a.AirlineCode != b.AirlineCode {
+		f.Airline = a.Airline + " + " + b.Airline
+		f.AirlineCode = a.AirlineCode + "+" + b.AirlineCode
+	}
+	if a.Cabin != b.Cabin {
+		f.Cabin = ""
+	}
+	if a.Aircraft != b.Aircraft {
+		f.Aircraft = a.Aircraft + " + " + b.Aircraft
+	}
+	if a.Provider != b.Provider {
+		f.Provider = a.Provider + " + " + b.Provider
+	}
+
+	return f
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bookcabin/internal/domain"
+	"bookcabin/internal/infra"
+)
+
+// routeProvider answers a search with its flights on the requested route
+// and date.
+type routeProvider struct {
+	flights []domain.Flight
+}
+
+func (p *routeProvider) Name() string { return "route" }
+
+func (p *routeProvider) Search(_ context.Context, req domain.SearchRequest) (domain.ProviderResult, error) {
+	var res domain.ProviderResult
+	for _, f := range p.flights {
+		if f.Origin == req.Origin && f.Destination == req.Destination &&
+			f.DepartureTime.Format(dateLayout) == req.DepartureDate {
+			res.Flights = append(res.Flights, f)
+		}
+	}
+	return res, nil
+}
+
+func TestSelfTransferFacetsIgnoreFilters(t *testing.T) {
+	day := time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC)
+	flight := func(code, from, to string, dep time.Duration, price int64) domain.Flight {
+		return domain.Flight{
+			FlightCode:     code,
+			AirlineCode:    code[:2],
+			Origin:         from,
+			Destination:    to,
+			DepartureTime:  day.Add(dep),
+			ArrivalTime:    day.Add(dep + 90*time.Minute),
+			DurationMin:    90,
+			PriceIDR:       price,
+			AvailableSeats: 9,
+		}
+	}
+
+	uc := &SearchFlightsUseCase{
+		Providers: []FlightProvider{&routeProvider{flights: []domain.Flight{
+			flight("GA100", "CGK", "SUB", 8*time.Hour, 500_000),
+			flight("JT200", "SUB", "LOP", 12*time.Hour, 700_000),
+			flight("JT202", "SUB", "LOP", 15*time.Hour, 1_500_000),
+		}}},
+		Cache:        infra.NewCache(),
+		SelfTransfer: SelfTransferConfig{Hubs: []string{"SUB"}},
+	}
+
+	tests := []struct {
+		name     string
+		maxPrice int64
+		want     int
+	}{
+		{name: "no filters", want: 2},
+		{name: "max_price", maxPrice: 1_500_000, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := domain.SearchRequest{
+				Origin:        "CGK",
+				Destination:   "LOP",
+				DepartureDate: "2025-12-15",
+				Passengers:    1,
+				MaxStops:      -1,
+				MaxPrice:      tt.maxPrice,
+				SelfTransfer:  true,
+			}
+
+			res, err := uc.Execute(context.Background(), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.SelfTransfers != tt.want {
+				t.Fatalf("self_transfers = %d, want %d", res.SelfTransfers, tt.want)
+			}
+
+			// facets cover both connections whatever the filters
+			if res.Facets == nil || res.Facets.Count != 2 {
+				t.Fatalf("facets = %+v, want both connections counted", res.Facets)
+			}
+			if res.Facets.Price.MinIDR != 1_200_000 || res.Facets.Price.MaxIDR != 2_000_000 {
+				t.Fatalf("facet price range = %d..%d, want 1200000..2000000", res.Facets.Price.MinIDR, res.Facets.Price.MaxIDR)
+			}
+		})
+	}
+}